@@ -5,6 +5,7 @@ import (
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
 	"image/color"
 	"math"
@@ -16,6 +17,7 @@ const (
 	stateMap
 	stateVisibilityGraph
 	stateShortestPath
+	stateVisibilityPolygon
 )
 
 //var currentState = stateInput
@@ -75,12 +77,18 @@ func inputState(game *Game, _ *sedv2.Map) {
 func mapState(game *Game, polygonMap *sedv2.Map) {
 	if game.obstaclesInput.Text != "" && game.sInput.Text != "" && game.tInput.Text != "" {
 		polygonMap.Clear()
-		polygonMap.AddObstacles(parseObstacles(game.obstaclesInput.Text)...)
 		polygonMap.S, _ = parsePoint(game.sInput.Text)
 		polygonMap.T, _ = parsePoint(game.tInput.Text)
+		if err := polygonMap.AddObstacles(sedv2.ParseObstacles(game.obstaclesInput.Text)...); err != nil {
+			updateWindow(game, drawObject(polygonMap))
+			dialog.ShowError(err, *game.window)
+			return
+		}
 	}
 	updateWindow(game, drawObject(polygonMap))
-	polygonMap.FindShortestPath()
+	if _, err := polygonMap.FindShortestPath(); err != nil {
+		dialog.ShowError(err, *game.window)
+	}
 }
 
 func visibilityGraphState(game *Game, polygonMap *sedv2.Map) {
@@ -92,13 +100,21 @@ func shortestPathState(game *Game, polygonMap *sedv2.Map) {
 	updateWindow(game, drawObject(polygonMap))
 }
 
-var stateFuncs = []func(*Game, *sedv2.Map){inputState, mapState, visibilityGraphState, shortestPathState}
+func visibilityPolygonState(game *Game, polygonMap *sedv2.Map) {
+	_, err := polygonMap.FindVisibilityPolygon(polygonMap.S)
+	updateWindow(game, drawObject(polygonMap))
+	if err != nil {
+		dialog.ShowError(err, *game.window)
+	}
+}
+
+var stateFuncs = []func(*Game, *sedv2.Map){inputState, mapState, visibilityGraphState, shortestPathState, visibilityPolygonState}
 
 func main() {
 	myApp := app.New()
 	window := myApp.NewWindow("Border Layout")
 
-	polygonMap := sedv2.NewMap(sedv2.Point{100, 100}, sedv2.Point{200, 200})
+	polygonMap := sedv2.NewMap(sedv2.Point{X: 100, Y: 100}, sedv2.Point{X: 200, Y: 200})
 
 	obstacles := []sedv2.Obstacle{
 		//sedv2.Obstacle{Vertices: []sedv2.Point{{-}},
@@ -110,7 +126,9 @@ func main() {
 		//sedv2.Obstacle{Vertices: []sedv2.Point{{40, 0}, {60, 70}, {30, 60}}},
 	}
 
-	polygonMap.AddObstacles(obstacles...)
+	if err := polygonMap.AddObstacles(obstacles...); err != nil {
+		panic(err)
+	}
 
 	g := Game{
 		polygonMap:   polygonMap,
@@ -128,16 +146,16 @@ func main() {
 			g.obstaclesInput.SetText("")
 			S, _ := parsePoint(g.sInput.Text)
 			T, _ := parsePoint(g.tInput.Text)
-			var minX, minY, maxX, maxY float32 = math.MaxFloat32, math.MaxFloat32, -math.MaxFloat32, -math.MaxFloat32
+			minX, minY, maxX, maxY := math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64
 			for _, p := range []sedv2.Point{S, T} {
-				minX = float32(math.Min(float64(minX), float64(p.X)))
-				minY = float32(math.Min(float64(minY), float64(p.Y)))
-				maxX = float32(math.Max(float64(maxX), float64(p.X)))
-				maxY = float32(math.Max(float64(maxY), float64(p.Y)))
+				minX = math.Min(minX, p.X)
+				minY = math.Min(minY, p.Y)
+				maxX = math.Max(maxX, p.X)
+				maxY = math.Max(maxY, p.Y)
 			}
 
-			obstacleNumX, obstacleNumY := int(math.Sqrt(float64(maxX-minX))), int(math.Sqrt(float64(maxY-minY)))
-			sizeX, sizeY := int((maxX-minX)/float32(obstacleNumX)), int((maxY-minY)/float32(obstacleNumY))
+			obstacleNumX, obstacleNumY := int(math.Sqrt(maxX-minX)), int(math.Sqrt(maxY-minY))
+			sizeX, sizeY := int((maxX-minX)/float64(obstacleNumX)), int((maxY-minY)/float64(obstacleNumY))
 			maxObstacles := obstacleNumX * obstacleNumY
 			currentObstacles := 0
 			for i := 0; i < obstacleNumX; i++ {
@@ -145,7 +163,7 @@ func main() {
 					break
 				}
 				for j := 0; j < obstacleNumY; j++ {
-					oMinX, oMinY, oMaxX, oMaxY := minX+float32(i*sizeX), minY+float32(j*sizeY), minX+float32((i+1)*sizeX), minY+float32((j+1)*sizeY)
+					oMinX, oMinY, oMaxX, oMaxY := minX+float64(i*sizeX), minY+float64(j*sizeY), minX+float64((i+1)*sizeX), minY+float64((j+1)*sizeY)
 
 					currentObstacles++
 					g.obstaclesInput.SetText(g.obstaclesInput.Text + "\n\n" + sedv2.CreateRandomObstacle(3, oMinX, oMinY, oMaxX, oMaxY).ToString())
@@ -0,0 +1,189 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Agent is one of several robots routed through a map at once, a disk of the
+// given Radius moving at most at Speed, the map's MaxSpeed if zero, from S to
+// T.
+type Agent struct {
+	S, T   Point
+	Radius float64
+	Speed  float64
+}
+
+// AgentConflict reports agents A and B coming closer than the sum of their
+// radii, first at the given Time.
+type AgentConflict struct {
+	A, B int
+	Time float64
+}
+
+// At returns where the path is at time t, at its start before it and at its
+// end after it.
+func (p TimedPath) At(t float64) Point {
+	return MovingObstacle{Trajectory: p.Points}.OffsetAt(t)
+}
+
+// FindMultiAgentPaths plans a timed path for every agent, see FindTimedPath,
+// such that no two agents collide, by prioritized planning: the agents are
+// planned one after another, each one avoiding the agents planned before it
+// as moving obstacles, on their way and after they arrive. When an agent
+// cannot be planned the agents are planned again with it first, at most once
+// per agent. The obstacles are grown by each agent's radius; the disks of
+// the other agents are approximated by polygons with the map's ArcSegments
+// sides, so agents keep slightly more than the sum of their radii apart.
+// Agents overlapping at their starts or at their targets cannot be planned.
+// Results.AgentPaths is set to the paths.
+//
+// Agents that have arrived stay at their targets and never step aside, so an
+// agent whose only way passes the target of an agent planned before it fails.
+// Planning it first helps when the other agent can wait for it to pass, but
+// when every order leaves some agent blocked by one that arrived before it
+// no paths are found, even if the agents could have let each other pass.
+func (m *Map) FindMultiAgentPaths(agents []Agent) ([]TimedPath, error) {
+	m.Results = Results{}
+	for a := range agents {
+		for b := a + 1; b < len(agents); b++ {
+			distance := agents[a].Radius + agents[b].Radius
+			switch {
+			case agents[a].S.Distance(agents[b].S) < distance:
+				return nil, fmt.Errorf("%w: agents %d and %d overlap at their starts", ErrNoPath, a, b)
+			case agents[a].T.Distance(agents[b].T) < distance:
+				return nil, fmt.Errorf("%w: agents %d and %d overlap at their targets", ErrNoPath, a, b)
+			}
+		}
+	}
+
+	order := make([]int, len(agents))
+	for i := range order {
+		order[i] = i
+	}
+
+	var err error
+	for attempt := 0; attempt < max(len(agents), 1); attempt++ {
+		var paths []TimedPath
+		var failed int
+		paths, failed, err = m.planAgents(agents, order)
+		if err == nil {
+			if conflicts := AgentConflicts(agents, paths); len(conflicts) > 0 {
+				c := conflicts[0]
+				return nil, fmt.Errorf("%w: agents %d and %d collide at time %v", ErrNoPath, c.A, c.B, c.Time)
+			}
+			m.Results.AgentPaths = paths
+			return paths, nil
+		}
+		if failed == order[0] || errors.Is(err, ErrOutsideBoundary) || errors.Is(err, ErrInvalidSpeed) {
+			break
+		}
+		order = append([]int{failed}, slices.DeleteFunc(order, func(i int) bool { return i == failed })...)
+	}
+	return nil, err
+}
+
+// planAgents plans the agents in the given order, returning the paths by agent
+// index, or the agent that could not be planned and why.
+func (m *Map) planAgents(agents []Agent, order []int) ([]TimedPath, int, error) {
+	arcSegments := m.ArcSegments
+	if arcSegments < 3 {
+		arcSegments = DefaultArcSegments
+	}
+
+	paths := make([]TimedPath, len(agents))
+	var planned []MovingObstacle
+	for _, i := range order {
+		agent := agents[i]
+		agentMap := *m
+		agentMap.S, agentMap.T = agent.S, agent.T
+		agentMap.robotShape = nil
+		// The map's prepared scene may be grown for its own robot, which the
+		// scene key does not tell apart
+		agentMap.scene, agentMap.pathMap = nil, nil
+		agentMap.RobotRadius = agent.Radius
+		if agent.Speed > 0 {
+			agentMap.MaxSpeed = agent.Speed
+		}
+		agentMap.movingObstacles = append(slices.Clip(m.movingObstacles), planned...)
+
+		path, err := agentMap.FindTimedPath()
+		if err != nil {
+			return nil, i, fmt.Errorf("agent %d: %w", i, err)
+		}
+		paths[i] = path
+		planned = append(planned, MovingObstacle{
+			Obstacle:   Obstacle{Vertices: circumscribedPolygon(agent.Radius, arcSegments)},
+			Trajectory: path.Points,
+		})
+	}
+	return paths, -1, nil
+}
+
+// AgentConflicts returns, for every pair of agents that come closer than the
+// sum of their radii when following the paths, the first time they do. Agents
+// stay where their paths end.
+func AgentConflicts(agents []Agent, paths []TimedPath) []AgentConflict {
+	var conflicts []AgentConflict
+	for a := range paths {
+		for b := a + 1; b < len(paths); b++ {
+			if t, ok := firstContact(paths[a], paths[b], agents[a].Radius+agents[b].Radius); ok {
+				conflicts = append(conflicts, AgentConflict{a, b, t})
+			}
+		}
+	}
+	return conflicts
+}
+
+// firstContact returns the first time the points following the paths come
+// closer than distance. Between the times either path bends at, the points
+// move linearly relative to each other, so the time solves a quadratic
+// equation.
+func firstContact(p, q TimedPath, distance float64) (float64, bool) {
+	var times []float64
+	for _, path := range []TimedPath{p, q} {
+		for _, point := range path.Points {
+			times = append(times, point.Time)
+		}
+	}
+	slices.Sort(times)
+	times = slices.Compact(times)
+	if len(times) == 0 {
+		return 0, false
+	}
+
+	// Agents closer than this at the start of an interval already conflict;
+	// the slack keeps agents planned to touch from being reported
+	tooClose := distance * (1 - 1e-9)
+	relative := func(t float64) Point {
+		a, b := p.At(t), q.At(t)
+		return Point{a.X - b.X, a.Y - b.Y}
+	}
+	for i, t0 := range times {
+		d0 := relative(t0)
+		if math.Hypot(d0.X, d0.Y) < tooClose {
+			return t0, true
+		}
+		if i+1 == len(times) {
+			break
+		}
+		t1 := times[i+1]
+		d1 := relative(t1)
+		v := Point{(d1.X - d0.X) / (t1 - t0), (d1.Y - d0.Y) / (t1 - t0)}
+
+		// |d0 + v s|² = tooClose² for the smaller root s
+		a := v.X*v.X + v.Y*v.Y
+		b := 2 * (d0.X*v.X + d0.Y*v.Y)
+		c := d0.X*d0.X + d0.Y*d0.Y - tooClose*tooClose
+		if a == 0 || b*b-4*a*c < 0 {
+			continue
+		}
+		s := (-b - math.Sqrt(b*b-4*a*c)) / (2 * a)
+		if 0 <= s && t0+s < t1 {
+			return t0 + s, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,158 @@
+package sedv2
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestFindMultiAgentPathsIgnoresPreparedScene(t *testing.T) {
+	agents := []Agent{
+		{S: Point{-100, 0}, T: Point{100, 0}, Radius: 5, Speed: 10},
+		{S: Point{0, -100}, T: Point{0, 100}, Radius: 5, Speed: 10},
+	}
+	newMap := func() *Map {
+		m := NewMap(Point{}, Point{})
+		m.MaxSpeed = 10
+		m.RobotRadius = 5
+		if err := m.AddObstacles(Obstacle{Vertices: []Point{{-50, 20}, {50, 20}, {50, 40}, {-50, 40}}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.SetRobotShape(Point{-30, -30}, Point{30, -30}, Point{30, 30}, Point{-30, 30}); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	fresh, primed := newMap(), newMap()
+	primed.Scene()
+	want, err := fresh.FindMultiAgentPaths(agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := primed.FindMultiAgentPaths(agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if !slices.Equal(got[i].Points, want[i].Points) {
+			t.Errorf("agent %d: got %v on a prepared map, want %v", i, got[i].Points, want[i].Points)
+		}
+	}
+}
+
+// checkAgentPaths checks that every agent's path leads from its S to its T and
+// that no two agents come closer than the sum of their radii, both by
+// AgentConflicts and by sampling the paths finely.
+func checkAgentPaths(t *testing.T, agents []Agent, paths []TimedPath) {
+	t.Helper()
+	end := 0.0
+	for i, path := range paths {
+		points := path.Points
+		if points[0].Point != agents[i].S || points[len(points)-1].Point != agents[i].T {
+			t.Errorf("agent %d: path %v does not lead from %v to %v", i, points, agents[i].S, agents[i].T)
+		}
+		end = max(end, points[len(points)-1].Time)
+	}
+	if conflicts := AgentConflicts(agents, paths); len(conflicts) > 0 {
+		t.Errorf("got conflicts %v", conflicts)
+	}
+	for step := 0; step <= 10000; step++ {
+		time := end * float64(step) / 10000
+		for a := range paths {
+			for b := a + 1; b < len(paths); b++ {
+				distance := paths[a].At(time).Distance(paths[b].At(time))
+				if distance < (agents[a].Radius+agents[b].Radius)*(1-1e-6) {
+					t.Fatalf("agents %d and %d are %v apart at time %v", a, b, distance, time)
+				}
+			}
+		}
+	}
+}
+
+func TestFindMultiAgentPathsCrossing(t *testing.T) {
+	// The straight paths all cross at the origin at the same time
+	agents := []Agent{
+		{S: Point{-100, 0}, T: Point{100, 0}, Radius: 5},
+		{S: Point{0, -100}, T: Point{0, 100}, Radius: 5},
+		{S: Point{100, 0}, T: Point{-100, 0}, Radius: 5},
+		{S: Point{-70, -70}, T: Point{70, 70}, Radius: 5, Speed: 10 * math.Sqrt2},
+	}
+	m := NewMap(Point{}, Point{})
+	m.MaxSpeed = 10
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{-20, 40}, {20, 40}, {20, 60}, {-20, 60}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := m.FindMultiAgentPaths(agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAgentPaths(t, agents, paths)
+	if !slices.EqualFunc(m.Results.AgentPaths, paths, func(a, b TimedPath) bool { return slices.Equal(a.Points, b.Points) }) {
+		t.Errorf("Results.AgentPaths is %v, want %v", m.Results.AgentPaths, paths)
+	}
+}
+
+// crossroads returns a map whose free space is two corridors crossing at the
+// origin, each too narrow for agents of radius 5 to pass each other in.
+func crossroads(t *testing.T) *Map {
+	m := NewMap(Point{}, Point{})
+	m.MaxSpeed = 10
+	var boundary []Point
+	for _, d := range []Point{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} {
+		// The corner before the corridor leading in direction d and its end,
+		// counterclockwise
+		across := Point{-d.Y, d.X}
+		boundary = append(boundary,
+			Point{d.X*8 - across.X*8, d.Y*8 - across.Y*8},
+			Point{d.X*100 - across.X*8, d.Y*100 - across.Y*8},
+			Point{d.X*100 + across.X*8, d.Y*100 + across.Y*8},
+		)
+	}
+	if err := m.SetBoundary(boundary...); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFindMultiAgentPathsReordersBlockedAgent(t *testing.T) {
+	// Planned first, agent 0 would stop in the upper corridor for good before
+	// agent 1 passes through it
+	agents := []Agent{
+		{S: Point{-90, 0}, T: Point{0, 50}, Radius: 5},
+		{S: Point{0, -90}, T: Point{0, 90}, Radius: 5},
+	}
+	m := crossroads(t)
+	if _, failed, err := m.planAgents(agents, []int{0, 1}); !errors.Is(err, ErrNoPath) || failed != 1 {
+		t.Fatalf("planning agent 0 first: got agent %d failing with %v, want agent 1 without a path", failed, err)
+	}
+
+	paths, err := m.FindMultiAgentPaths(agents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkAgentPaths(t, agents, paths)
+}
+
+func TestFindMultiAgentPathsAgentsDoNotYield(t *testing.T) {
+	// Agent 1 could step into the right corridor to let agent 0 pass, but in
+	// either order the agent planned first stops in the way of the other
+	agents := []Agent{
+		{S: Point{-90, 0}, T: Point{0, 50}, Radius: 5},
+		{S: Point{0, 90}, T: Point{-50, 0}, Radius: 5},
+	}
+	m := crossroads(t)
+	for i, agent := range agents {
+		if _, _, err := m.planAgents([]Agent{agent}, []int{0}); err != nil {
+			t.Fatalf("agent %d alone: %v", i, err)
+		}
+	}
+	if _, err := m.FindMultiAgentPaths(agents); !errors.Is(err, ErrNoPath) {
+		t.Fatalf("got %v, want ErrNoPath", err)
+	}
+	if m.Results.AgentPaths != nil {
+		t.Errorf("Results.AgentPaths is %v, want none", m.Results.AgentPaths)
+	}
+}
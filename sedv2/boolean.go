@@ -0,0 +1,485 @@
+package sedv2
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrOrphanHole is reported when a hole of the result of a boolean operation
+// lies inside none of its outlines, which invalid operands, or the rounding of
+// the points where nearly parallel edges cross, can cause.
+var ErrOrphanHole = errors.New("sedv2: hole outside of every outline of the result")
+
+// Union returns the area covered by a or b as a set of obstacles with holes.
+// The boolean operations treat obstacles as the areas inside their outlines
+// and outside their holes; their Bounding flag is ignored and the results are
+// never bounding. Where the area of a result pinches to a single point, its
+// rings share that vertex. Should a hole of the result lie inside none of its
+// outlines, nothing is returned and the error wraps ErrOrphanHole.
+func Union(a, b Obstacle) ([]Obstacle, error) {
+	return overlay([]Obstacle{a, b}, func(covered []bool) bool {
+		return covered[0] || covered[1]
+	})
+}
+
+// Intersection returns the area covered by both a and b.
+func Intersection(a, b Obstacle) ([]Obstacle, error) {
+	return overlay([]Obstacle{a, b}, func(covered []bool) bool {
+		return covered[0] && covered[1]
+	})
+}
+
+// Difference returns the area covered by a but not by b.
+func Difference(a, b Obstacle) ([]Obstacle, error) {
+	return overlay([]Obstacle{a, b}, func(covered []bool) bool {
+		return covered[0] && !covered[1]
+	})
+}
+
+// UnionAll merges every group of overlapping or touching obstacles into the
+// obstacles covering their union. Obstacles meeting no other are returned
+// unchanged, collinear vertices included. The obstacles meeting a bounding
+// obstacle are merged into it, see clipToBoundaries, and the bounding
+// obstacles are returned last. The obstacles of a group whose union fails, see
+// Union, are returned as they are, along with the error.
+func UnionAll(obstacles ...Obstacle) ([]Obstacle, error) {
+	var merged, bounding []Obstacle
+	var candidates []Obstacle
+	for _, obstacle := range obstacles {
+		if obstacle.Bounding {
+			bounding = append(bounding, obstacle)
+		} else {
+			candidates = append(candidates, obstacle)
+		}
+	}
+
+	// Group the obstacles that overlap or touch
+	group := make([]int, len(candidates))
+	for i := range candidates {
+		group[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			if find(i) != find(j) && obstaclesMeet(candidates[i], candidates[j]) {
+				group[find(i)] = find(j)
+			}
+		}
+	}
+
+	members := make(map[int][]Obstacle)
+	var order []int
+	for i, obstacle := range candidates {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			order = append(order, root)
+		}
+		members[root] = append(members[root], obstacle)
+	}
+	var errs []error
+	for _, root := range order {
+		if len(members[root]) == 1 {
+			merged = append(merged, members[root]...)
+			continue
+		}
+		union, err := overlay(members[root], func(covered []bool) bool {
+			return slices.Contains(covered, true)
+		})
+		if err != nil {
+			errs = append(errs, err)
+			union = members[root]
+		}
+		merged = append(merged, union...)
+	}
+
+	clipped, err := clipToBoundaries(append(merged, bounding...))
+	return clipped, errors.Join(append(errs, err)...)
+}
+
+// clipToBoundaries merges the obstacles that cross or touch a bounding
+// obstacle, or lie outside of it, into the latter: the free space inside it
+// loses the area they cover, and they are dropped. Otherwise a path could run
+// along the boundary between an obstacle and the wall it meets. Should the
+// free space split into several parts, the largest is kept, and should it
+// vanish, a bounding obstacle without vertices blocks everything, as for
+// MinkowskiSum. The obstacles clear of the boundary keep their order, the
+// bounding obstacles following them. A bounding obstacle whose overlay fails,
+// see Union, is returned with the obstacles meeting it as they are, along with
+// the error.
+func clipToBoundaries(obstacles []Obstacle) ([]Obstacle, error) {
+	var inside, bounding []Obstacle
+	for _, obstacle := range obstacles {
+		if obstacle.Bounding {
+			bounding = append(bounding, obstacle)
+		} else {
+			inside = append(inside, obstacle)
+		}
+	}
+
+	var errs []error
+	for i, boundary := range bounding {
+		var meeting []Obstacle
+		inside = slices.DeleteFunc(inside, func(obstacle Obstacle) bool {
+			if obstaclesMeet(obstacle, boundary) {
+				meeting = append(meeting, obstacle)
+				return true
+			}
+			return false
+		})
+		if len(meeting) == 0 {
+			continue
+		}
+
+		free, err := overlay(append([]Obstacle{boundary}, meeting...), func(covered []bool) bool {
+			return covered[0] && !slices.Contains(covered[1:], true)
+		})
+		if err != nil {
+			errs = append(errs, err)
+			inside = append(inside, meeting...)
+			continue
+		}
+		if len(free) == 0 {
+			bounding[i] = Obstacle{Bounding: true}
+			continue
+		}
+		largest := slices.MaxFunc(free, func(a, b Obstacle) int {
+			return cmp.Compare(ringArea(a.Vertices), ringArea(b.Vertices))
+		})
+		largest.Bounding = true
+		bounding[i] = largest
+	}
+
+	return append(inside, bounding...), errors.Join(errs...)
+}
+
+// obstaclesMeet reports whether the areas the obstacles block overlap or
+// touch: whether their edges cross or touch, or one of them lies in the area
+// the other blocks. A bounding obstacle without vertices meets everything.
+func obstaclesMeet(a, b Obstacle) bool {
+	if len(a.Vertices) == 0 || len(b.Vertices) == 0 {
+		return a.Bounding || b.Bounding
+	}
+	box := newBoundingBox(a.vertices())
+	for _, edge := range b.edges() {
+		if !box.overlaps(newBoundingBox([]Point{edge.start, edge.end})) {
+			continue
+		}
+		for _, e := range a.edges() {
+			if doSegmentsIntersectAlternative(e.start, e.end, edge.start, edge.end) {
+				return true
+			}
+		}
+	}
+	return a.Contains(b.Vertices[0]) || b.Contains(a.Vertices[0])
+}
+
+// overlayEdge is an edge of one of the operands of an overlay, with the side
+// of the operand's interior.
+type overlayEdge struct {
+	Segment
+	operand      int
+	interiorLeft bool
+	splits       []Point
+}
+
+// overlay computes a boolean combination of the operands. The operands' edges
+// are split where they meet each other, and each piece is kept when inside
+// tells that the result covers the area on one side of it but not on the
+// other. The pieces kept, directed to have the result on their left, are then
+// linked into rings. Holes inside none of the outlines are reported, see
+// assembleObstacles.
+func overlay(operands []Obstacle, inside func(covered []bool) bool) ([]Obstacle, error) {
+	operands = slices.Clone(operands)
+	boxes := make([]boundingBox, len(operands))
+	var edges []overlayEdge
+	for i := range operands {
+		operands[i].Bounding = false
+		boxes[i] = newBoundingBox(operands[i].Vertices)
+		for r, ring := range operands[i].rings() {
+			interiorLeft := operands[i].winding(r) > 0
+			for j := range ring {
+				edges = append(edges, overlayEdge{
+					Segment:      Segment{ring[j], ring[(j+1)%len(ring)]},
+					operand:      i,
+					interiorLeft: interiorLeft,
+				})
+			}
+		}
+	}
+
+	splitEdges(edges)
+
+	// The sides of every piece covered by the operands it lies on, keyed by
+	// the piece in comparePoints order
+	type pieceSides struct {
+		left, right map[int]bool
+	}
+	pieces := make(map[Segment]*pieceSides)
+	var keys []Segment
+	for _, edge := range edges {
+		for k := 0; k+1 < len(edge.splits); k++ {
+			piece := Segment{edge.splits[k], edge.splits[k+1]}
+			key := piece.normalized()
+			sides, ok := pieces[key]
+			if !ok {
+				sides = &pieceSides{left: make(map[int]bool), right: make(map[int]bool)}
+				pieces[key] = sides
+				keys = append(keys, key)
+			}
+			if (key == piece) == edge.interiorLeft {
+				sides.left[edge.operand] = true
+			} else {
+				sides.right[edge.operand] = true
+			}
+		}
+	}
+
+	next := make(map[Point][]Point)
+	degree := make(map[Point]int)
+	var starts []Segment
+	coveredLeft, coveredRight := make([]bool, len(operands)), make([]bool, len(operands))
+	for _, key := range keys {
+		sides := pieces[key]
+		midpoint := Point{(key.start.X + key.end.X) / 2, (key.start.Y + key.end.Y) / 2}
+		for i, operand := range operands {
+			switch {
+			case sides.left[i] || sides.right[i]:
+				// The operand lies on one side of its own edge, or on both
+				// when the piece is a slit inside it
+				coveredLeft[i], coveredRight[i] = sides.left[i], sides.right[i]
+			case boxes[i].contains(midpoint):
+				coveredLeft[i] = operand.Contains(midpoint)
+				coveredRight[i] = coveredLeft[i]
+			default:
+				coveredLeft[i], coveredRight[i] = false, false
+			}
+		}
+
+		edge := key
+		switch inLeft, inRight := inside(coveredLeft), inside(coveredRight); {
+		case inLeft == inRight:
+			continue
+		case inRight:
+			edge = Segment{key.end, key.start}
+		}
+		next[edge.start] = append(next[edge.start], edge.end)
+		degree[edge.start]++
+		degree[edge.end]++
+		starts = append(starts, edge)
+	}
+
+	slices.SortFunc(starts, func(a, b Segment) int {
+		if order := comparePoints(a.start, b.start); order != 0 {
+			return order
+		}
+		return comparePoints(a.end, b.end)
+	})
+	var rings [][]Point
+	used := make(map[Segment]bool)
+	for _, start := range starts {
+		if used[start] {
+			continue
+		}
+		rings = append(rings, splitRing(traceRing(start, next, used), degree)...)
+	}
+
+	return assembleObstacles(rings)
+}
+
+// splitEdges fills the splits of every edge with its endpoints and the points
+// where it meets edges of other operands, ordered from its start to its end.
+func splitEdges(edges []overlayEdge) {
+	boxes := make([]boundingBox, len(edges))
+	for i := range edges {
+		edges[i].splits = []Point{edges[i].start, edges[i].end}
+		boxes[i] = newBoundingBox(edges[i].splits)
+	}
+
+	for i := range edges {
+		e := &edges[i]
+		for j := i + 1; j < len(edges); j++ {
+			f := &edges[j]
+			if e.operand == f.operand || !boxes[i].overlaps(boxes[j]) {
+				continue
+			}
+
+			d1, d2 := orientation(e.start, e.end, f.start), orientation(e.start, e.end, f.end)
+			d3, d4 := orientation(f.start, f.end, e.start), orientation(f.start, f.end, e.end)
+			if d1*d2 < 0 && d3*d4 < 0 {
+				p := crossingPoint(e.Segment, f.Segment)
+				e.splits = append(e.splits, p)
+				f.splits = append(f.splits, p)
+				continue
+			}
+
+			// Endpoints lying on the other edge, which also covers collinear
+			// overlaps
+			if d1 == 0 && isBetween(e.start, e.end, f.start) {
+				e.splits = append(e.splits, f.start)
+			}
+			if d2 == 0 && isBetween(e.start, e.end, f.end) {
+				e.splits = append(e.splits, f.end)
+			}
+			if d3 == 0 && isBetween(f.start, f.end, e.start) {
+				f.splits = append(f.splits, e.start)
+			}
+			if d4 == 0 && isBetween(f.start, f.end, e.end) {
+				f.splits = append(f.splits, e.end)
+			}
+		}
+	}
+
+	for i := range edges {
+		e := &edges[i]
+		slices.SortFunc(e.splits, func(a, b Point) int {
+			da, db := e.start.Distance(a), e.start.Distance(b)
+			if da != db {
+				if da < db {
+					return -1
+				}
+				return 1
+			}
+			return comparePoints(a, b)
+		})
+		e.splits = slices.Compact(e.splits)
+	}
+}
+
+// crossingPoint returns the point where the segments a and b properly cross,
+// computed in floating point rather than exactly like the predicates, so it
+// may lie off either segment by a rounding error. Segments so close to
+// parallel that the crossing cannot be computed in floating point are taken
+// to meet midway along a.
+func crossingPoint(a, b Segment) Point {
+	dx1, dy1 := a.end.X-a.start.X, a.end.Y-a.start.Y
+	dx2, dy2 := b.end.X-b.start.X, b.end.Y-b.start.Y
+	t := ((b.start.X-a.start.X)*dy2 - (b.start.Y-a.start.Y)*dx2) / (dx1*dy2 - dy1*dx2)
+	if math.IsNaN(t) {
+		t = 0.5
+	}
+	t = max(0, min(1, t))
+	return Point{a.start.X + t*dx1, a.start.Y + t*dy1}
+}
+
+// traceRing follows the edges of the result from start until it returns to
+// it. Where several edges leave a vertex it takes the sharpest left turn,
+// keeping to the boundary of a single face.
+func traceRing(start Segment, next map[Point][]Point, used map[Segment]bool) []Point {
+	var ring []Point
+	for edge := start; !used[edge]; {
+		used[edge] = true
+		ring = append(ring, edge.start)
+
+		v := edge.end
+		var best Point
+		found := false
+		for _, w := range next[v] {
+			if out := (Segment{v, w}); used[out] && out != start {
+				continue
+			}
+			if !found || compareLeftTurns(v, edge.start, w, best) < 0 {
+				best, found = w, true
+			}
+		}
+		edge = Segment{v, best}
+	}
+	return ring
+}
+
+// compareLeftTurns orders the ways from v to a and to b by the clockwise angle
+// from the way back to u, in (0, 2π], so that the sharpest left turn comes
+// first and the way straight back last. Turning clockwise from the way back,
+// the ways at a smaller angle from the x axis than it are reached first, and
+// within either group the ways at larger angles.
+func compareLeftTurns(v, u, a, b Point) int {
+	aBefore, bBefore := compareAngle(v, a, u) < 0, compareAngle(v, b, u) < 0
+	if aBefore != bBefore {
+		if aBefore {
+			return -1
+		}
+		return 1
+	}
+	return -compareAngle(v, a, b)
+}
+
+// splitRing splits a ring visiting some vertex more than once into rings
+// visiting every vertex once, and drops the vertices in the middle of a
+// straight run unless other edges of the result meet there.
+func splitRing(ring []Point, degree map[Point]int) [][]Point {
+	var rings [][]Point
+	var stack []Point
+	position := make(map[Point]int)
+	for _, v := range append(ring, ring[0]) {
+		if i, ok := position[v]; ok {
+			rings = append(rings, slices.Clone(stack[i:]))
+			for _, w := range stack[i:] {
+				delete(position, w)
+			}
+			stack = stack[:i]
+		}
+		position[v] = len(stack)
+		stack = append(stack, v)
+	}
+
+	simple := rings[:0]
+	for _, ring := range rings {
+		n := len(ring)
+		var straightened []Point
+		for i, v := range ring {
+			if degree[v] > 2 || orientation(ring[(i+n-1)%n], v, ring[(i+1)%n]) != 0 {
+				straightened = append(straightened, v)
+			}
+		}
+		if len(straightened) >= 3 {
+			simple = append(simple, straightened)
+		}
+	}
+	return simple
+}
+
+// assembleObstacles turns the counter-clockwise rings into outlines and puts
+// every clockwise ring into the smallest outline containing it as a hole. A
+// hole inside none of the outlines is reported with an error wrapping
+// ErrOrphanHole, and nothing is returned.
+func assembleObstacles(rings [][]Point) ([]Obstacle, error) {
+	var obstacles []Obstacle
+	var holes [][]Point
+	for _, ring := range rings {
+		if ringArea(ring) > 0 {
+			obstacles = append(obstacles, Obstacle{Vertices: ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	var errs []error
+	for _, hole := range holes {
+		midpoint := Point{(hole[0].X + hole[1].X) / 2, (hole[0].Y + hole[1].Y) / 2}
+		owner := -1
+		for i, obstacle := range obstacles {
+			if isInsideRing(midpoint, obstacle.Vertices) &&
+				(owner < 0 || ringArea(obstacle.Vertices) < ringArea(obstacles[owner].Vertices)) {
+				owner = i
+			}
+		}
+		if owner < 0 {
+			errs = append(errs, fmt.Errorf("%w: %v", ErrOrphanHole, hole))
+			continue
+		}
+		obstacles[owner].Holes = append(obstacles[owner].Holes, hole)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return obstacles, nil
+}
@@ -0,0 +1,180 @@
+package sedv2
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// obstaclesArea returns the area the obstacles cover, which must be
+// normalized and must not overlap.
+func obstaclesArea(obstacles ...Obstacle) float64 {
+	area := 0.0
+	for _, obstacle := range obstacles {
+		for _, ring := range obstacle.rings() {
+			area += ringArea(ring)
+		}
+	}
+	return area
+}
+
+func TestBooleanOperationAreas(t *testing.T) {
+	square := func(x, y, size float64) Obstacle {
+		return Obstacle{Vertices: []Point{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}}
+	}
+	cases := []struct {
+		name string
+		a, b Obstacle
+	}{
+		{"sharing an edge", square(0, 0, 10), square(10, 0, 10)},
+		{"sharing part of an edge", square(0, 0, 10), square(10, 5, 10)},
+		{"sharing a corner", square(0, 0, 10), square(10, 10, 10)},
+		{"nested", square(0, 0, 10), square(2, 2, 5)},
+		{"identical", square(0, 0, 10), square(0, 0, 10)},
+		{"disjoint", square(0, 0, 10), square(20, 0, 10)},
+		{"with a hole", Obstacle{
+			Vertices: []Point{{0, 0}, {30, 0}, {30, 30}, {0, 30}},
+			Holes:    [][]Point{{{10, 10}, {10, 20}, {20, 20}, {20, 10}}},
+		}, square(5, 5, 10)},
+	}
+	r := rand.New(rand.NewPCG(1, 0))
+	for i := 0; i < 200; i++ {
+		a := randomStarObstacle(r, Point{50, 50}, 10, 40)
+		b := randomStarObstacle(r, Point{30 + 40*r.Float64(), 30 + 40*r.Float64()}, 10, 40)
+		cases = append(cases, struct {
+			name string
+			a, b Obstacle
+		}{fmt.Sprintf("random %d", i), a, b})
+	}
+
+	for _, c := range cases {
+		obstacles, err := NormalizeObstacles(c.a, c.b)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		a, b := obstacles[0], obstacles[1]
+		areaA, areaB := obstaclesArea(a), obstaclesArea(b)
+		area := func(obstacles []Obstacle, err error) float64 {
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			return obstaclesArea(obstacles...)
+		}
+		union := area(Union(a, b))
+		intersection := area(Intersection(a, b))
+		difference := area(Difference(a, b))
+		merged := area(UnionAll(a, b))
+
+		tolerance := 1e-9 * (areaA + areaB)
+		if math.Abs(union+intersection-areaA-areaB) > tolerance {
+			t.Errorf("%s: |A∪B| + |A∩B| = %v + %v, want |A| + |B| = %v + %v", c.name, union, intersection, areaA, areaB)
+		}
+		if math.Abs(difference-(areaA-intersection)) > tolerance {
+			t.Errorf("%s: |A\\B| = %v, want |A| - |A∩B| = %v - %v", c.name, difference, areaA, intersection)
+		}
+		if math.Abs(merged-union) > tolerance {
+			t.Errorf("%s: UnionAll covers %v, want |A∪B| = %v", c.name, merged, union)
+		}
+	}
+}
+
+func TestCompareLeftTurns(t *testing.T) {
+	// Arriving at the origin from the west, the ways out from the sharpest
+	// left turn to the way straight back
+	v, u := Point{0, 0}, Point{-1, 0}
+	ways := []Point{{-1, 1}, {0, 1}, {1, 1}, {1, 0}, {1, -1}, {0, -1}, {-1, -1}, {-2, 0}}
+	for i, a := range ways {
+		for j, b := range ways {
+			if got, want := compareLeftTurns(v, u, a, b), cmp.Compare(i, j); got != want {
+				t.Errorf("compareLeftTurns(%v, %v, %v, %v) = %d, want %d", v, u, a, b, got, want)
+			}
+		}
+	}
+}
+
+func TestUnionAllKeepsObstaclesMeetingNoOther(t *testing.T) {
+	// The square sits in the notch of the L, inside its bounding box but
+	// clear of it, and the L has a vertex in the middle of its bottom edge
+	l := Obstacle{Vertices: []Point{{0, 0}, {5, 0}, {10, 0}, {10, 4}, {4, 4}, {4, 10}, {0, 10}}}
+	square := Obstacle{Vertices: []Point{{6, 6}, {9, 6}, {9, 9}, {6, 9}}}
+	merged, err := UnionAll(l, square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 2 || !slices.Equal(merged[0].Vertices, l.Vertices) || !slices.Equal(merged[1].Vertices, square.Vertices) {
+		t.Errorf("UnionAll = %v, want the obstacles unchanged", merged)
+	}
+
+	touching := Obstacle{Vertices: []Point{{4, 6}, {9, 6}, {9, 9}, {4, 9}}}
+	if merged, err = UnionAll(l, touching); err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 {
+		t.Errorf("UnionAll = %v, want the touching obstacles merged", merged)
+	}
+}
+
+func TestOrphanHolesAreReported(t *testing.T) {
+	// An invalid operand whose hole lies outside of its outline
+	a := Obstacle{
+		Vertices: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		Holes:    [][]Point{{{20, 20}, {20, 30}, {30, 30}, {30, 20}}},
+	}
+	b := Obstacle{Vertices: []Point{{5, 5}, {15, 5}, {15, 15}, {5, 15}}}
+	union, err := Union(a, b)
+	if !errors.Is(err, ErrOrphanHole) || union != nil {
+		t.Errorf("Union = %v, %v, want an error wrapping ErrOrphanHole", union, err)
+	}
+	merged, err := UnionAll(a, b)
+	if !errors.Is(err, ErrOrphanHole) || len(merged) != 2 {
+		t.Errorf("UnionAll = %v, %v, want the obstacles unmerged and an error wrapping ErrOrphanHole", merged, err)
+	}
+}
+
+func TestMergedScenesMatchValidUnmergedPaths(t *testing.T) {
+	compared := 0
+	for seed := uint64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		var obstacles []Obstacle
+		for i := 0; i < 5; i++ {
+			obstacles = append(obstacles, randomStarObstacle(r, Point{40 + 120*r.Float64(), 40 + 120*r.Float64()}, 10, 35))
+		}
+		obstacles = append(obstacles, Obstacle{Vertices: []Point{{0, 0}, {200, 0}, {200, 200}, {0, 200}}, Bounding: true})
+		free := func() Point {
+			for {
+				p := Point{200 * r.Float64(), 200 * r.Float64()}
+				if isPathClear([]Point{p}, obstacles) {
+					return p
+				}
+			}
+		}
+		S, T := free(), free()
+
+		for _, builder := range []GraphBuilder{LeeBuilder, RotationTreeBuilder} {
+			unmergedGraph := PrepareScene(obstacles, GraphOptions{Builder: builder}).VisibilityGraph(S, T)
+			unmerged, unmergedErr := unmergedGraph.ShortestPath(Dijkstra)
+			mergedGraph := PrepareScene(obstacles, GraphOptions{Builder: builder, MergeOverlapping: true}).VisibilityGraph(S, T)
+			merged, err := mergedGraph.ShortestPath(Dijkstra)
+			if err == nil && !isPathClear(merged.Path, obstacles) {
+				t.Errorf("seed %d builder %d: merged path %v runs through an obstacle", seed, builder, merged.Path)
+			}
+			if unmergedErr != nil || !isPathClear(unmerged.Path, obstacles) {
+				continue
+			}
+			compared++
+			if err != nil {
+				t.Errorf("seed %d builder %d: %v, want the unmerged path %v", seed, builder, err, unmerged.Path)
+			} else if math.Abs(merged.Length-unmerged.Length) > 1e-6 {
+				t.Errorf("seed %d builder %d: merged path %v of length %v, want the unmerged path %v of length %v",
+					seed, builder, merged.Path, merged.Length, unmerged.Path, unmerged.Length)
+			}
+		}
+	}
+	if compared == 0 {
+		t.Error("no valid unmerged path to compare with")
+	}
+}
@@ -0,0 +1,312 @@
+package sedv2
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// DefaultRoadmapResolution is how many times the distance between the points
+// sampled along the obstacle edges to build a clearance roadmap fits along the
+// diagonal of the scene's bounding box when no distance is given, so that the
+// roadmap's size does not depend on the scale of the coordinates.
+const DefaultRoadmapResolution = 100
+
+// ClearancePath is a path keeping as far from the obstacles as the roadmap it
+// was found on allows. Clearance is the smallest distance between the path and
+// an obstacle.
+type ClearancePath struct {
+	Path      []Point
+	Length    float64
+	Clearance float64
+}
+
+// FindMaxClearancePath finds a path from S to T along the medial axis of the
+// free space of the map's Scene, the set of points at equal distance from two
+// or more obstacle edges. The medial axis is approximated by the Voronoi
+// diagram of points sampled RoadmapSpacing apart along the obstacle edges;
+// without a boundary it does not extend far beyond the obstacles, S and T.
+// S and T are joined to nearby roadmap vertices they see. Of the paths
+// maximizing the smallest clearance along the roadmap the shortest is
+// returned; the clearance of the segments joining S and T is not considered
+// in the choice, since it is bounded by the clearance of S and T themselves,
+// but it is in the reported Clearance. Results.VisibilityGraph is set to the
+// roadmap.
+func (m *Map) FindMaxClearancePath() (ClearancePath, error) {
+	m.Results = Results{}
+	if err := m.checkEndpoints(); err != nil {
+		return ClearancePath{}, err
+	}
+
+	obstacles := m.Scene().obstacles
+	spacing := m.RoadmapSpacing
+	if spacing <= 0 {
+		points := []Point{m.S, m.T}
+		for _, obstacle := range obstacles {
+			points = append(points, obstacle.vertices()...)
+		}
+		box := newBoundingBox(points)
+		spacing = box.min.Distance(box.max) / DefaultRoadmapResolution
+		if spacing == 0 {
+			spacing = 1
+		}
+	}
+	roadmap := newClearanceRoadmap(obstacles, spacing, m.S, m.T)
+	visibilityGraph := roadmap.visibilityGraph(m.S, m.T)
+	m.Results.VisibilityGraph = &visibilityGraph
+
+	result, err := roadmap.search(m.S, m.T)
+	m.Results.Path = result.Path
+	m.Results.Length = result.Length
+	return result, err
+}
+
+// clearanceRoadmap holds the edges of the approximate medial axis of the free
+// space together with their clearance.
+type clearanceRoadmap struct {
+	obstacles []Obstacle
+	edges     map[Point][]Point
+	clearance map[Segment]float64
+}
+
+// roadmapSite is a point sampled on the given edge of the given ring of an
+// obstacle.
+type roadmapSite struct {
+	obstacle, ring, edge int
+}
+
+// isNeighbour reports whether the sites lie on the same or on neighbouring
+// edges of a ring. The Voronoi edges between them only lead towards the
+// obstacle, away from the medial axis.
+func (s roadmapSite) isNeighbour(other roadmapSite, ringSize int) bool {
+	if s.obstacle != other.obstacle || s.ring != other.ring {
+		return false
+	}
+	d := (s.edge - other.edge + ringSize) % ringSize
+	return d == 0 || d == 1 || d == ringSize-1
+}
+
+// newClearanceRoadmap builds the roadmap of the free space between the
+// obstacles. Without a bounding obstacle the free space is closed off by a
+// frame around the obstacles and the given points, a quarter of its size away
+// from them, which only shapes the roadmap and does not count as an obstacle.
+func newClearanceRoadmap(obstacles []Obstacle, spacing float64, enclosed ...Point) *clearanceRoadmap {
+	roadmap := &clearanceRoadmap{
+		obstacles: obstacles,
+		edges:     make(map[Point][]Point),
+		clearance: make(map[Segment]float64),
+	}
+
+	sampledObstacles := obstacles
+	if !slices.ContainsFunc(obstacles, func(o Obstacle) bool { return o.Bounding }) {
+		var points []Point
+		for _, obstacle := range obstacles {
+			points = append(points, obstacle.vertices()...)
+		}
+		box := newBoundingBox(append(points, enclosed...))
+		margin := max(box.max.X-box.min.X, box.max.Y-box.min.Y, spacing) / 4
+		frame := Obstacle{Vertices: []Point{
+			{box.min.X - margin, box.min.Y - margin},
+			{box.max.X + margin, box.min.Y - margin},
+			{box.max.X + margin, box.max.Y + margin},
+			{box.min.X - margin, box.max.Y + margin},
+		}}
+		sampledObstacles = append(slices.Clip(obstacles), frame)
+	}
+
+	var points []Point
+	var sites []roadmapSite
+	var ringSizes []int
+	sampled := make(map[Point]bool)
+	for o, obstacle := range sampledObstacles {
+		for r, ring := range obstacle.rings() {
+			for e, start := range ring {
+				end := ring[(e+1)%len(ring)]
+				samples := int(math.Ceil(start.Distance(end) / spacing))
+				for k := 0; k < samples; k++ {
+					t := float64(k) / float64(samples)
+					p := Point{start.X + t*(end.X-start.X), start.Y + t*(end.Y-start.Y)}
+					if sampled[p] {
+						continue
+					}
+					sampled[p] = true
+					points = append(points, p)
+					sites = append(sites, roadmapSite{o, r, e})
+					ringSizes = append(ringSizes, len(ring))
+				}
+			}
+		}
+	}
+
+	// Every Delaunay edge between two triangles is dual to the Voronoi edge
+	// joining their circumcentres
+	triangles := delaunayTriangulation(points)
+	adjacent := make(map[[2]int][]int)
+	for i, t := range triangles {
+		for k := 0; k < 3; k++ {
+			a, b := t.corners[k], t.corners[(k+1)%3]
+			adjacent[[2]int{min(a, b), max(a, b)}] = append(adjacent[[2]int{min(a, b), max(a, b)}], i)
+		}
+	}
+
+	// Circumcentres of cocircular sites differ by rounding errors only, so
+	// they are snapped to a fine grid to become a single roadmap vertex
+	grid := spacing * 1e-6
+	snap := func(p Point) Point {
+		return Point{math.Round(p.X/grid) * grid, math.Round(p.Y/grid) * grid}
+	}
+	for edge, shared := range adjacent {
+		if len(shared) != 2 || sites[edge[0]].isNeighbour(sites[edge[1]], ringSizes[edge[0]]) {
+			continue
+		}
+		u, v := snap(triangles[shared[0]].center), snap(triangles[shared[1]].center)
+		if u == v || roadmap.isBlocked(u) || roadmap.isBlocked(v) || !isSegmentFree(u, v, obstacles) {
+			continue
+		}
+		roadmap.addEdge(u, v, segmentClearance(u, v, obstacles))
+	}
+
+	return roadmap
+}
+
+func (r *clearanceRoadmap) isBlocked(p Point) bool {
+	for _, obstacle := range r.obstacles {
+		if obstacle.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *clearanceRoadmap) addEdge(u, v Point, clearance float64) {
+	if _, ok := r.clearance[Segment{u, v}]; ok {
+		return
+	}
+	r.edges[u] = append(r.edges[u], v)
+	r.edges[v] = append(r.edges[v], u)
+	r.clearance[Segment{u, v}], r.clearance[Segment{v, u}] = clearance, clearance
+}
+
+// connect joins p to the nearest roadmap vertex it sees without coming closer
+// to an obstacle than p itself is, or if there is none, to the one it sees
+// over the segment of the largest clearance. The joining edge gets an infinite
+// clearance, so that it does not limit the search: the clearance of p bounds
+// that of every path from it anyway.
+func (r *clearanceRoadmap) connect(p Point) {
+	limit := segmentClearance(p, p, r.obstacles)
+	var best Point
+	bestClearance := -1.0
+	for v := range r.edges {
+		if v == p || !isSegmentFree(p, v, r.obstacles) {
+			continue
+		}
+		clearance := min(segmentClearance(p, v, r.obstacles), limit)
+		if clearance > bestClearance || clearance == bestClearance && p.Distance(v) < p.Distance(best) {
+			best, bestClearance = v, clearance
+		}
+	}
+	if bestClearance >= 0 {
+		r.addEdge(p, best, math.Inf(1))
+	}
+}
+
+// visibilityGraph returns the roadmap as a visibility graph from start to
+// target, for drawing.
+func (r *clearanceRoadmap) visibilityGraph(start, target Point) VisibilityGraph {
+	visibilityGraph := NewVisibilityGraph(start, target)
+	for v, neighbors := range r.edges {
+		visibilityGraph.AddEdges(v, neighbors)
+	}
+	return visibilityGraph
+}
+
+// search joins start and target to the roadmap, finds the largest clearance
+// any path between them keeps with a widest path search, and returns the
+// shortest path keeping it.
+func (r *clearanceRoadmap) search(start, target Point) (ClearancePath, error) {
+	r.connect(start)
+	r.connect(target)
+	if start != target && isSegmentFree(start, target, r.obstacles) {
+		r.addEdge(start, target, segmentClearance(start, target, r.obstacles))
+	}
+
+	widest := map[Point]float64{start: math.Inf(1)}
+	settled := make(map[Point]bool)
+	pq := NewPriorityQueue()
+	pq.PushPoint(start, math.Inf(-1))
+	for !pq.IsEmpty() {
+		v, _ := pq.PopPoint()
+		if settled[v] {
+			continue
+		}
+		settled[v] = true
+		if v == target {
+			break
+		}
+		for _, u := range r.edges[v] {
+			width := min(widest[v], r.clearance[Segment{v, u}])
+			if known, ok := widest[u]; !settled[u] && (!ok || width > known) {
+				widest[u] = width
+				pq.PushPoint(u, -width)
+			}
+		}
+	}
+	if !settled[target] {
+		return ClearancePath{}, fmt.Errorf("%w: %v cannot be reached from %v", ErrNoPath, target, start)
+	}
+
+	visibilityGraph := NewVisibilityGraph(start, target)
+	for v, neighbors := range r.edges {
+		for _, u := range neighbors {
+			if r.clearance[Segment{v, u}] >= widest[target] {
+				visibilityGraph.AddEdges(v, []Point{u})
+			}
+		}
+	}
+	result, err := visibilityGraph.ShortestPath(Dijkstra)
+	if err != nil {
+		return ClearancePath{}, err
+	}
+
+	clearance := math.Inf(1)
+	for i := 0; i+1 < len(result.Path); i++ {
+		clearance = min(clearance, segmentClearance(result.Path[i], result.Path[i+1], r.obstacles))
+	}
+	if len(result.Path) == 1 {
+		clearance = segmentClearance(start, start, r.obstacles)
+	}
+	return ClearancePath{Path: result.Path, Length: result.Length, Clearance: clearance}, nil
+}
+
+// segmentClearance returns the distance between the segment ab and the
+// nearest obstacle edge.
+func segmentClearance(a, b Point, S []Obstacle) float64 {
+	clearance := math.Inf(1)
+	for _, obstacle := range S {
+		for _, edge := range obstacle.edges() {
+			clearance = min(clearance, segmentDistance(a, b, edge.start, edge.end))
+		}
+	}
+	return clearance
+}
+
+// segmentDistance returns the distance between the segments ab and cd.
+func segmentDistance(a, b, c, d Point) float64 {
+	if doSegmentsIntersect(a, b, c, d) {
+		return 0
+	}
+	return min(pointSegmentDistance(a, c, d), pointSegmentDistance(b, c, d),
+		pointSegmentDistance(c, a, b), pointSegmentDistance(d, a, b))
+}
+
+// pointSegmentDistance returns the distance between p and the segment ab.
+func pointSegmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return p.Distance(a)
+	}
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / lengthSq
+	t = max(0, min(1, t))
+	return p.Distance(Point{a.X + t*dx, a.Y + t*dy})
+}
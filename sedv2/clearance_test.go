@@ -0,0 +1,89 @@
+package sedv2
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestFindMaxClearancePathScaleInvariant(t *testing.T) {
+	find := func(scale float64) ClearancePath {
+		at := func(x, y float64) Point { return Point{x * scale, y * scale} }
+		m := NewMap(at(10, 50), at(90, 50))
+		if err := m.SetBoundary(at(0, 0), at(100, 0), at(100, 100), at(0, 100)); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.AddObstacles(Obstacle{Vertices: []Point{at(40, 30), at(60, 30), at(60, 70), at(40, 70)}}); err != nil {
+			t.Fatal(err)
+		}
+		path, err := m.FindMaxClearancePath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	small, large := find(1), find(1e4)
+	if len(small.Path) != len(large.Path) {
+		t.Fatalf("paths of %d and %d points", len(small.Path), len(large.Path))
+	}
+	if ratio := large.Clearance / small.Clearance; math.Abs(ratio-1e4) > 1e-3 {
+		t.Errorf("clearance %v scaled to %v", small.Clearance, large.Clearance)
+	}
+}
+
+// TestFindMaxClearancePathTakesWideCorridor puts a block in a room, leaving a
+// corridor 15 wide above it and one 30 wide below it. The shortest path
+// squeezes through the narrow corridor, touching the block's corners, while
+// the path of maximum clearance follows the middle of the wide corridor,
+// keeping 15 away from the walls.
+func TestFindMaxClearancePathTakesWideCorridor(t *testing.T) {
+	m := NewMap(Point{15, 60}, Point{85, 60})
+	m.RoadmapSpacing = 1
+	if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{30, 30}, {70, 30}, {70, 85}, {30, 85}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	shortest, err := m.FindShortestPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortestLength := m.Results.Length
+	obstacles := m.Scene().Obstacles()
+	shortestClearance := math.Inf(1)
+	for i := 0; i+1 < len(shortest); i++ {
+		shortestClearance = min(shortestClearance, segmentClearance(shortest[i], shortest[i+1], obstacles))
+	}
+
+	result, err := m.FindMaxClearancePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Clearance < 14 || result.Clearance <= shortestClearance || result.Length <= shortestLength {
+		t.Errorf("path %v of length %v and clearance %v, want a clearance near 15, above the shortest path's %v of length %v",
+			result.Path, result.Length, result.Clearance, shortestClearance, shortestLength)
+	}
+	passing := 0
+	for _, p := range result.Path {
+		if p.X > 35 && p.X < 65 {
+			passing++
+			if math.Abs(p.Y-15) > 1 {
+				t.Errorf("path %v passes the block at %v, want it along the middle of the wide corridor", result.Path, p)
+			}
+		}
+	}
+	if passing == 0 {
+		t.Errorf("path %v has no vertex alongside the block", result.Path)
+	}
+
+	// Between the segments joining S and T the path runs along the roadmap
+	roadmap := m.Results.VisibilityGraph
+	for i := 1; i+2 < len(result.Path); i++ {
+		if !slices.Contains(roadmap.Neighbors(result.Path[i]), result.Path[i+1]) {
+			t.Errorf("path %v leaves the roadmap between %v and %v", result.Path, result.Path[i], result.Path[i+1])
+		}
+	}
+}
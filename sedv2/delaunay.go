@@ -0,0 +1,124 @@
+package sedv2
+
+// delaunayTriangle holds the indices of its corners in counter-clockwise order
+// and the centre of its circumcircle. One corner may be the vertex at
+// infinity, ghost, making it a ghost triangle outside an edge of the convex
+// hull.
+type delaunayTriangle struct {
+	corners [3]int
+	center  Point
+}
+
+// ghost is the index of the vertex at infinity.
+const ghost = -1
+
+func newDelaunayTriangle(points []Point, a, b, c int) delaunayTriangle {
+	t := delaunayTriangle{corners: [3]int{a, b, c}}
+	if t.isGhost() {
+		return t
+	}
+	pa, pb, pc := points[a], points[b], points[c]
+	bx, by := pb.X-pa.X, pb.Y-pa.Y
+	cx, cy := pc.X-pa.X, pc.Y-pa.Y
+	d := 2 * (bx*cy - by*cx)
+	ux := (cy*(bx*bx+by*by) - by*(cx*cx+cy*cy)) / d
+	uy := (bx*(cx*cx+cy*cy) - cx*(bx*bx+by*by)) / d
+	t.center = Point{pa.X + ux, pa.Y + uy}
+	return t
+}
+
+func (t delaunayTriangle) isGhost() bool {
+	return t.corners[0] == ghost || t.corners[1] == ghost || t.corners[2] == ghost
+}
+
+// circumcircleContains reports, exactly, whether p lies strictly inside the
+// triangle's circumcircle. The circumcircle of a ghost triangle is the open
+// half-plane beyond its hull edge, together with the inside of the edge.
+func (t delaunayTriangle) circumcircleContains(points []Point, p Point) bool {
+	if !t.isGhost() {
+		return inCircle(points[t.corners[0]], points[t.corners[1]], points[t.corners[2]], p) > 0
+	}
+	// Rotated so that the ghost comes last, the triangle is u, v, ghost with
+	// the hull to the right of uv
+	k := 0
+	for t.corners[(k+2)%3] != ghost {
+		k++
+	}
+	u, v := points[t.corners[k]], points[t.corners[(k+1)%3]]
+	switch orientation(u, v, p) {
+	case 1:
+		return true
+	case 0:
+		return p != u && p != v && isBetween(u, v, p)
+	}
+	return false
+}
+
+// delaunayTriangulation triangulates the distinct points with the
+// Bowyer-Watson algorithm: every point is inserted by replacing the triangles
+// whose circumcircle contains it by a fan around it. The convex hull is
+// closed off by ghost triangles sharing a vertex at infinity, so the result
+// covers it exactly and no enclosing triangle has to be guessed. Collinear
+// points have no triangulation.
+func delaunayTriangulation(points []Point) []delaunayTriangle {
+	// Start from the first three points that make a triangle
+	first := -1
+	for i := 2; i < len(points) && first < 0; i++ {
+		if orientation(points[0], points[1], points[i]) != 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	a, b, c := 0, 1, first
+	if orientation(points[a], points[b], points[c]) < 0 {
+		b, c = c, b
+	}
+	triangles := []delaunayTriangle{
+		newDelaunayTriangle(points, a, b, c),
+		newDelaunayTriangle(points, b, a, ghost),
+		newDelaunayTriangle(points, c, b, ghost),
+		newDelaunayTriangle(points, a, c, ghost),
+	}
+
+	for i, p := range points {
+		if i == a || i == b || i == c {
+			continue
+		}
+
+		// The edges of the cavity are the directed edges of bad triangles
+		// whose reverse belongs to none
+		var edges [][2]int
+		directed := make(map[[2]int]bool)
+		kept := triangles[:0]
+		for _, t := range triangles {
+			if !t.circumcircleContains(points, p) {
+				kept = append(kept, t)
+				continue
+			}
+			for k := 0; k < 3; k++ {
+				edge := [2]int{t.corners[k], t.corners[(k+1)%3]}
+				edges = append(edges, edge)
+				directed[edge] = true
+			}
+		}
+		triangles = kept
+
+		// The cavity is star shaped around p, so the fan keeps the
+		// triangles counter-clockwise and none of them is degenerate
+		for _, edge := range edges {
+			if !directed[[2]int{edge[1], edge[0]}] {
+				triangles = append(triangles, newDelaunayTriangle(points, edge[0], edge[1], i))
+			}
+		}
+	}
+
+	result := triangles[:0]
+	for _, t := range triangles {
+		if !t.isGhost() {
+			result = append(result, t)
+		}
+	}
+	return result
+}
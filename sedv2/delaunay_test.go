@@ -0,0 +1,63 @@
+package sedv2
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestDelaunayTriangulation(t *testing.T) {
+	var lattice []Point
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			lattice = append(lattice, Point{float64(x), float64(y)})
+		}
+	}
+	r := rand.New(rand.NewPCG(1, 0))
+	var random []Point
+	for i := 0; i < 200; i++ {
+		random = append(random, Point{1e6 + r.Float64(), 1e6 + r.Float64()})
+	}
+
+	for _, test := range []struct {
+		name   string
+		points []Point
+	}{
+		{"boundary and square", sampleRings(3,
+			[]Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}},
+			[]Point{{40, 40}, {60, 40}, {60, 60}, {40, 60}},
+		)},
+		{"lattice", lattice},
+		{"collinear", []Point{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {1.5, 1}}},
+		{"random", random},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			triangles := delaunayTriangulation(test.points)
+			area := 0.0
+			for _, triangle := range triangles {
+				a, b, c := test.points[triangle.corners[0]], test.points[triangle.corners[1]], test.points[triangle.corners[2]]
+				if orientation(a, b, c) <= 0 {
+					t.Fatalf("triangle %v %v %v is not counter-clockwise", a, b, c)
+				}
+				area += ringArea([]Point{a, b, c})
+				for _, p := range test.points {
+					if inCircle(a, b, c, p) > 0 {
+						t.Fatalf("%v lies inside the circumcircle of %v %v %v", p, a, b, c)
+					}
+				}
+			}
+			if len(triangles) == 0 {
+				t.Fatal("no triangles")
+			}
+			if hull := ringArea(convexHull(test.points)); math.Abs(area-hull) > 1e-9*hull {
+				t.Errorf("triangles cover %v, want the hull's %v", area, hull)
+			}
+		})
+	}
+}
+
+func TestDelaunayTriangulationCollinear(t *testing.T) {
+	if triangles := delaunayTriangulation([]Point{{0, 0}, {1, 1}, {2, 2}, {5, 5}}); triangles != nil {
+		t.Errorf("collinear points triangulated into %v", triangles)
+	}
+}
@@ -0,0 +1,358 @@
+package sedv2
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// ErrNoSmoothPath is reported when no collision-free curvature-bounded path is
+// found.
+var ErrNoSmoothPath = errors.New("sedv2: no collision-free curvature-bounded path")
+
+const (
+	// smoothingRoutes is the number of shortest paths tried in turn when the
+	// smoothed ones collide.
+	smoothingRoutes = 8
+	// smoothingSplits bounds how often a leg is split in halves when none of
+	// the Dubins curves joining its ends is collision-free.
+	smoothingSplits = 4
+	// arcStep is the largest angle between the points sampled along an arc.
+	// The chord between two of them strays at most r(1-cos(arcStep/2)), about
+	// 0.12% of the turning radius r, from the arc.
+	arcStep = math.Pi / 32
+)
+
+// Pose is a position together with the heading a vehicle faces there, in
+// radians counter-clockwise from the X axis.
+type Pose struct {
+	Point   Point
+	Heading float64
+}
+
+// DubinsSegmentKind tells how a vehicle steers along a segment of a Dubins
+// curve.
+type DubinsSegmentKind int
+
+const (
+	TurnLeft DubinsSegmentKind = iota
+	Straight
+	TurnRight
+)
+
+// DubinsSegment is a part of a Dubins curve. The Length of a turn is the angle
+// turned times the turning radius.
+type DubinsSegment struct {
+	Kind   DubinsSegmentKind
+	Length float64
+}
+
+// DubinsCurve is a curve made of three segments, each an arc of the minimum
+// turning radius or a straight line, that a car-like vehicle can drive from
+// its Start pose.
+type DubinsCurve struct {
+	Start    Pose
+	Radius   float64
+	Segments [3]DubinsSegment
+}
+
+// dubinsWords are the kinds of segments of the six families of Dubins curves,
+// one of which contains the shortest curve between any two poses.
+var dubinsWords = [][3]DubinsSegmentKind{
+	{TurnLeft, Straight, TurnLeft},
+	{TurnRight, Straight, TurnRight},
+	{TurnLeft, Straight, TurnRight},
+	{TurnRight, Straight, TurnLeft},
+	{TurnRight, TurnLeft, TurnRight},
+	{TurnLeft, TurnRight, TurnLeft},
+}
+
+// DubinsPath returns the shortest curve from one pose to another whose
+// curvature never exceeds that of the given turning radius.
+func DubinsPath(from, to Pose, radius float64) DubinsCurve {
+	return dubinsCurves(from, to, radius)[0]
+}
+
+// dubinsTolerance is how far rounding may push the quantities deciding whether
+// a family of curves joins two poses, computed for a unit turning radius, out
+// of their range when a segment of the curve vanishes.
+const dubinsTolerance = 1e-9
+
+// dubinsCurves returns the curves of every family that can join the poses,
+// shortest first.
+func dubinsCurves(from, to Pose, radius float64) []DubinsCurve {
+	dx, dy := to.Point.X-from.Point.X, to.Point.Y-from.Point.Y
+	d := math.Hypot(dx, dy) / radius
+	theta := mod2Pi(math.Atan2(dy, dx))
+	a, b := mod2Pi(from.Heading-theta), mod2Pi(to.Heading-theta)
+	sa, sb, ca, cb := math.Sin(a), math.Sin(b), math.Cos(a), math.Cos(b)
+
+	var curves []DubinsCurve
+	for _, word := range dubinsWords {
+		var t, p, q float64
+		switch word {
+		case dubinsWords[0]:
+			sq := 2 + d*d - 2*math.Cos(a-b) + 2*d*(sa-sb)
+			if sq < -dubinsTolerance {
+				continue
+			}
+			sq = max(sq, 0)
+			angle := math.Atan2(cb-ca, d+sa-sb)
+			t, p, q = mod2Pi(-a+angle), math.Sqrt(sq), mod2Pi(b-angle)
+		case dubinsWords[1]:
+			sq := 2 + d*d - 2*math.Cos(a-b) + 2*d*(sb-sa)
+			if sq < -dubinsTolerance {
+				continue
+			}
+			sq = max(sq, 0)
+			angle := math.Atan2(ca-cb, d-sa+sb)
+			t, p, q = mod2Pi(a-angle), math.Sqrt(sq), mod2Pi(-b+angle)
+		case dubinsWords[2]:
+			sq := -2 + d*d + 2*math.Cos(a-b) + 2*d*(sa+sb)
+			if sq < -dubinsTolerance {
+				continue
+			}
+			sq = max(sq, 0)
+			p = math.Sqrt(sq)
+			angle := math.Atan2(-ca-cb, d+sa+sb) - math.Atan2(-2, p)
+			t, q = mod2Pi(-a+angle), mod2Pi(-b+angle)
+		case dubinsWords[3]:
+			sq := d*d - 2 + 2*math.Cos(a-b) - 2*d*(sa+sb)
+			if sq < -dubinsTolerance {
+				continue
+			}
+			sq = max(sq, 0)
+			p = math.Sqrt(sq)
+			angle := math.Atan2(ca+cb, d-sa-sb) - math.Atan2(2, p)
+			t, q = mod2Pi(a-angle), mod2Pi(b-angle)
+		case dubinsWords[4]:
+			c := (6 - d*d + 2*math.Cos(a-b) + 2*d*(sa-sb)) / 8
+			if math.Abs(c) > 1+dubinsTolerance {
+				continue
+			}
+			c = max(-1, min(1, c))
+			p = mod2Pi(2*math.Pi - math.Acos(c))
+			t = mod2Pi(a - math.Atan2(ca-cb, d-sa+sb) + p/2)
+			q = mod2Pi(a - b - t + p)
+		case dubinsWords[5]:
+			c := (6 - d*d + 2*math.Cos(a-b) + 2*d*(sb-sa)) / 8
+			if math.Abs(c) > 1+dubinsTolerance {
+				continue
+			}
+			c = max(-1, min(1, c))
+			p = mod2Pi(2*math.Pi - math.Acos(c))
+			t = mod2Pi(-a - math.Atan2(ca-cb, d+sa-sb) + p/2)
+			q = mod2Pi(b - a - t + p)
+		}
+		curves = append(curves, DubinsCurve{
+			Start:  from,
+			Radius: radius,
+			Segments: [3]DubinsSegment{
+				{word[0], t * radius},
+				{word[1], p * radius},
+				{word[2], q * radius},
+			},
+		})
+	}
+
+	slices.SortStableFunc(curves, func(c1, c2 DubinsCurve) int {
+		return cmp.Compare(c1.Length(), c2.Length())
+	})
+	return curves
+}
+
+func (c DubinsCurve) Length() float64 {
+	return c.Segments[0].Length + c.Segments[1].Length + c.Segments[2].Length
+}
+
+// Points returns points sampled along the curve, its ends included. Points on
+// an arc are at most arcStep apart in angle, so the polyline through them
+// stays within about 0.12% of the radius of the curve.
+func (c DubinsCurve) Points() []Point {
+	points := []Point{c.Start.Point}
+	pose := c.Start
+	for _, segment := range c.Segments {
+		steps := 1
+		if segment.Kind != Straight {
+			steps = int(math.Ceil(segment.Length / c.Radius / arcStep))
+		}
+		for i := 1; i <= steps; i++ {
+			points = append(points, c.advance(pose, segment.Kind, segment.Length*float64(i)/float64(steps)).Point)
+		}
+		pose = c.advance(pose, segment.Kind, segment.Length)
+	}
+	return points
+}
+
+// End returns the pose the curve ends in.
+func (c DubinsCurve) End() Pose {
+	pose := c.Start
+	for _, segment := range c.Segments {
+		pose = c.advance(pose, segment.Kind, segment.Length)
+	}
+	return pose
+}
+
+// advance returns the pose reached from the given one after driving the given
+// length steering the given way.
+func (c DubinsCurve) advance(pose Pose, kind DubinsSegmentKind, length float64) Pose {
+	p, h := pose.Point, pose.Heading
+	switch kind {
+	case TurnLeft:
+		phi := length / c.Radius
+		return Pose{Point{p.X + c.Radius*(math.Sin(h+phi)-math.Sin(h)), p.Y - c.Radius*(math.Cos(h+phi)-math.Cos(h))}, h + phi}
+	case TurnRight:
+		phi := length / c.Radius
+		return Pose{Point{p.X - c.Radius*(math.Sin(h-phi)-math.Sin(h)), p.Y + c.Radius*(math.Cos(h-phi)-math.Cos(h))}, h - phi}
+	default:
+		return Pose{Point{p.X + length*math.Cos(h), p.Y + length*math.Sin(h)}, h}
+	}
+}
+
+func mod2Pi(angle float64) float64 {
+	angle = math.Mod(angle, 2*math.Pi)
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return angle
+}
+
+// SmoothPath is a curvature-bounded path following the polyline Waypoints,
+// made of one or more Dubins curves per leg of it.
+type SmoothPath struct {
+	Waypoints []Point
+	Curves    []DubinsCurve
+	Length    float64
+}
+
+// Points returns points sampled along the path, see DubinsCurve.Points.
+func (p SmoothPath) Points() []Point {
+	var points []Point
+	for i, curve := range p.Curves {
+		curvePoints := curve.Points()
+		if i > 0 {
+			curvePoints = curvePoints[1:]
+		}
+		points = append(points, curvePoints...)
+	}
+	return points
+}
+
+// FindSmoothPath finds a path from S to T that a car-like vehicle with the
+// map's TurningRadius can drive. The shortest path is followed through its
+// vertices, facing along the bisector of the turn at each of them and along
+// the path at S and T, by the shortest Dubins curves between consecutive
+// poses. A curve hitting an obstacle of the map's Scene is replaced by a
+// longer one joining the same poses, or the leg is split in halves at a pose
+// facing along it. When that fails the next shortest paths are tried, up to
+// a few; if none can be smoothed the error wraps ErrNoSmoothPath. Collisions
+// are checked along the polyline through the points sampled on the curves,
+// not the arcs themselves, so an arc may cut into an obstacle by up to about
+// 0.12% of the turning radius; grow the obstacles by that much, through the
+// RobotRadius, where this matters. Results.Path is set to the path that was
+// smoothed and Results.Curves to the curves, and both are cleared on failure.
+func (m *Map) FindSmoothPath() (SmoothPath, error) {
+	m.Results = Results{}
+	if !(m.TurningRadius > 0) {
+		return SmoothPath{}, fmt.Errorf("%w: turning radius %v is not positive", ErrNoSmoothPath, m.TurningRadius)
+	}
+	routes, err := m.FindKShortestPaths(smoothingRoutes)
+	if err != nil {
+		return SmoothPath{}, err
+	}
+
+	obstacles := m.Scene().obstacles
+	for _, route := range routes {
+		curves, ok := smoothPolyline(route.Path, m.TurningRadius, obstacles)
+		if !ok {
+			continue
+		}
+		smooth := SmoothPath{Waypoints: route.Path, Curves: curves}
+		for _, curve := range curves {
+			smooth.Length += curve.Length()
+		}
+		m.Results = Results{Path: route.Path, Length: route.Length, Curves: curves}
+		return smooth, nil
+	}
+	m.Results = Results{}
+	return SmoothPath{}, fmt.Errorf("%w: none of the %d shortest paths could be smoothed", ErrNoSmoothPath, len(routes))
+}
+
+// smoothPolyline joins the vertices of the polyline by collision-free Dubins
+// curves, reporting false if it cannot.
+func smoothPolyline(path []Point, radius float64, obstacles []Obstacle) ([]DubinsCurve, bool) {
+	if len(path) < 2 {
+		return nil, true
+	}
+
+	poses := make([]Pose, len(path))
+	for i, p := range path {
+		var dx, dy float64
+		if i > 0 {
+			length := path[i-1].Distance(p)
+			dx, dy = (p.X-path[i-1].X)/length, (p.Y-path[i-1].Y)/length
+		}
+		if i+1 < len(path) {
+			length := p.Distance(path[i+1])
+			dx, dy = dx+(path[i+1].X-p.X)/length, dy+(path[i+1].Y-p.Y)/length
+		}
+		if dx == 0 && dy == 0 {
+			// The path turns back on itself, so face along its next leg
+			dx, dy = path[i+1].X-p.X, path[i+1].Y-p.Y
+		}
+		poses[i] = Pose{p, math.Atan2(dy, dx)}
+	}
+
+	var curves []DubinsCurve
+	for i := 0; i+1 < len(poses); i++ {
+		leg, ok := smoothLeg(poses[i], poses[i+1], radius, obstacles, smoothingSplits)
+		if !ok {
+			return nil, false
+		}
+		curves = append(curves, leg...)
+	}
+	return curves, true
+}
+
+// smoothLeg returns collision-free Dubins curves from one pose to the next,
+// splitting the leg at most the given number of times.
+func smoothLeg(from, to Pose, radius float64, obstacles []Obstacle, splits int) ([]DubinsCurve, bool) {
+	for _, curve := range dubinsCurves(from, to, radius) {
+		if isCurveFree(curve, to.Point, obstacles) {
+			return []DubinsCurve{curve}, true
+		}
+	}
+	if splits == 0 {
+		return nil, false
+	}
+
+	mid := Pose{
+		Point:   Point{(from.Point.X + to.Point.X) / 2, (from.Point.Y + to.Point.Y) / 2},
+		Heading: math.Atan2(to.Point.Y-from.Point.Y, to.Point.X-from.Point.X),
+	}
+	first, ok := smoothLeg(from, mid, radius, obstacles, splits-1)
+	if !ok {
+		return nil, false
+	}
+	second, ok := smoothLeg(mid, to, radius, obstacles, splits-1)
+	if !ok {
+		return nil, false
+	}
+	return append(first, second...), true
+}
+
+// isCurveFree reports whether the polyline through the points sampled along
+// the curve avoids the obstacles. Its last point is taken to be end exactly,
+// as rounding errors would otherwise let it stray into an obstacle it touches.
+func isCurveFree(curve DubinsCurve, end Point, obstacles []Obstacle) bool {
+	points := curve.Points()
+	points[len(points)-1] = end
+	for i := 0; i+1 < len(points); i++ {
+		if points[i] != points[i+1] && !isSegmentFree(points[i], points[i+1], obstacles) {
+			return false
+		}
+	}
+	return true
+}
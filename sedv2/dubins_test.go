@@ -0,0 +1,107 @@
+package sedv2
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestFindSmoothPathClearsResultsOnFailure(t *testing.T) {
+	m := NewMap(Point{10, 10}, Point{90, 10})
+	if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{45, 0}, {55, 0}, {55, 80}, {45, 80}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.FindShortestPath(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.TurningRadius = 1000
+	if _, err := m.FindSmoothPath(); !errors.Is(err, ErrNoSmoothPath) {
+		t.Fatalf("got %v, want ErrNoSmoothPath", err)
+	}
+	if m.Results.Path != nil || m.Results.Curves != nil || m.Results.VisibilityGraph != nil {
+		t.Errorf("results of the failed search kept: %+v", m.Results)
+	}
+}
+
+func TestDubinsCurvePointsStayNearArcs(t *testing.T) {
+	curve := DubinsPath(Pose{Point{0, 0}, 0}, Pose{Point{3, 1}, math.Pi}, 2)
+	points := curve.Points()
+	tolerance := curve.Radius * (1 - math.Cos(arcStep/2))
+	pose := curve.Start
+	for _, segment := range curve.Segments {
+		// Every point of the arc lies within the tolerance of the polyline
+		for i := 0; i <= 1000; i++ {
+			p := curve.advance(pose, segment.Kind, segment.Length*float64(i)/1000).Point
+			nearest := math.Inf(1)
+			for k := 0; k+1 < len(points); k++ {
+				nearest = min(nearest, pointSegmentDistance(p, points[k], points[k+1]))
+			}
+			if nearest > tolerance*(1+1e-9) {
+				t.Fatalf("%v is %v from the sampled polyline, more than %v", p, nearest, tolerance)
+			}
+		}
+		pose = curve.advance(pose, segment.Kind, segment.Length)
+	}
+}
+
+func TestDubinsCurvesReachTheGoalPose(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 0))
+	for i := 0; i < 500; i++ {
+		from := Pose{Point{100 * r.Float64(), 100 * r.Float64()}, 2 * math.Pi * r.Float64()}
+		to := Pose{Point{100 * r.Float64(), 100 * r.Float64()}, 2 * math.Pi * r.Float64()}
+		radius := 1 + 30*r.Float64()
+
+		curves := dubinsCurves(from, to, radius)
+		if len(curves) == 0 {
+			t.Fatalf("no curve from %v to %v", from, to)
+		}
+		for _, curve := range curves {
+			end := curve.End()
+			heading := math.Abs(mod2Pi(end.Heading-to.Heading+math.Pi) - math.Pi)
+			if end.Point.Distance(to.Point) > 1e-9*100 || heading > 1e-9 {
+				t.Errorf("curve %v from %v ends at %v, want %v", curve.Segments, from, end, to)
+			}
+			for _, segment := range curve.Segments {
+				if segment.Length < 0 {
+					t.Errorf("curve %v from %v has a negative segment", curve.Segments, from)
+				}
+			}
+		}
+
+		shortest := DubinsPath(from, to, radius)
+		for _, curve := range curves {
+			if shortest.Length() > curve.Length() {
+				t.Errorf("DubinsPath from %v to %v has length %v, the curve %v only %v",
+					from, to, shortest.Length(), curve.Segments, curve.Length())
+			}
+		}
+	}
+}
+
+func TestDubinsPathLengths(t *testing.T) {
+	origin := Pose{Point{0, 0}, 0}
+	for _, test := range []struct {
+		name string
+		to   Pose
+		want float64
+	}{
+		{"straight ahead", Pose{Point{10, 0}, 0}, 10},
+		{"quarter turn left", Pose{Point{2, 2}, math.Pi / 2}, math.Pi},
+		{"quarter turn right", Pose{Point{2, -2}, -math.Pi / 2}, math.Pi},
+		{"half turn", Pose{Point{0, 4}, math.Pi}, 2 * math.Pi},
+		{"turn then straight", Pose{Point{2, 7}, math.Pi / 2}, math.Pi + 5},
+	} {
+		curve := DubinsPath(origin, test.to, 2)
+		if got := curve.Length(); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: length %v, want %v", test.name, got, test.want)
+		}
+		if end := curve.End(); end.Point.Distance(test.to.Point) > 1e-9 || math.Abs(end.Heading-test.to.Heading) > 1e-9 {
+			t.Errorf("%s: curve %v ends at %v, want %v", test.name, curve.Segments, end, test.to)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package sedv2
+
+import (
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// randomCellObstacle returns a star shaped obstacle inside the 100 by 100 cell
+// at the given column and row, so that obstacles in different cells never meet.
+func randomCellObstacle(r *rand.Rand, column, row int) Obstacle {
+	cx, cy := float64(column*100+50), float64(row*100+50)
+	n := 3 + r.IntN(5)
+	vertices := make([]Point, n)
+	for k := range vertices {
+		angle := 2 * math.Pi * (float64(k) + 0.8*r.Float64()) / float64(n)
+		radius := 15 + 30*r.Float64()
+		vertices[k] = Point{cx + radius*math.Cos(angle), cy + radius*math.Sin(angle)}
+	}
+	return Obstacle{Vertices: vertices}
+}
+
+// randomBoxes returns axis-aligned boxes on a grid, whose edges line up with
+// each other so that many segments between their vertices run along edges.
+func randomBoxes(r *rand.Rand, count int) []Obstacle {
+	var obstacles []Obstacle
+	for _, cell := range r.Perm(4 * count)[:count] {
+		x, y := float64(cell%4*20), float64(cell/4*20)
+		w, h := float64(5+5*r.IntN(2)), float64(5+5*r.IntN(2))
+		obstacles = append(obstacles, Obstacle{Vertices: []Point{{x, y}, {x + w, y}, {x + w, y + h}, {x, y + h}}})
+	}
+	return obstacles
+}
+
+// sortedEdges returns the scene's edges with every vertex's neighbours sorted,
+// for comparing graphs built in different orders.
+func sortedEdges(s *Scene) map[Point][]Point {
+	edges := make(map[Point][]Point, len(s.edges))
+	for v, neighbors := range s.edges {
+		edges[v] = slices.Clone(neighbors)
+		slices.SortFunc(edges[v], comparePoints)
+	}
+	return edges
+}
+
+func checkSameEdges(t *testing.T, step string, got, want *Scene) {
+	t.Helper()
+	g, w := sortedEdges(got), sortedEdges(want)
+	if maps.EqualFunc(g, w, slices.Equal) {
+		return
+	}
+	for v, neighbors := range w {
+		if !slices.Equal(g[v], neighbors) {
+			t.Fatalf("%s: %v has neighbours %v, want %v", step, v, g[v], neighbors)
+		}
+	}
+	for v, neighbors := range g {
+		if _, ok := w[v]; !ok {
+			t.Fatalf("%s: %v has neighbours %v, want none", step, v, neighbors)
+		}
+	}
+}
+
+// randomStarObstacle returns a star shaped obstacle around center whose
+// vertices lie between minRadius and maxRadius from it.
+func randomStarObstacle(r *rand.Rand, center Point, minRadius, maxRadius float64) Obstacle {
+	n := 3 + r.IntN(6)
+	vertices := make([]Point, n)
+	for k := range vertices {
+		angle := 2 * math.Pi * (float64(k) + 0.8*r.Float64()) / float64(n)
+		radius := minRadius + (maxRadius-minRadius)*r.Float64()
+		vertices[k] = Point{center.X + radius*math.Cos(angle), center.Y + radius*math.Sin(angle)}
+	}
+	return Obstacle{Vertices: vertices}
+}
+
+// sampleRings returns points spacing apart along the edges of the rings.
+func sampleRings(spacing float64, rings ...[]Point) []Point {
+	var points []Point
+	for _, ring := range rings {
+		for i, start := range ring {
+			end := ring[(i+1)%len(ring)]
+			samples := int(math.Ceil(start.Distance(end) / spacing))
+			for k := 0; k < samples; k++ {
+				t := float64(k) / float64(samples)
+				points = append(points, Point{start.X + t*(end.X-start.X), start.Y + t*(end.Y-start.Y)})
+			}
+		}
+	}
+	return points
+}
+
+// isPathClear reports whether no point of the path lies farther than 1e-7
+// inside an obstacle, checked at points spaced 0.5 apart along it.
+func isPathClear(path []Point, obstacles []Obstacle) bool {
+	var samples []Point
+	for i := 0; i+1 < len(path); i++ {
+		samples = append(samples, sampleRings(0.5, path[i:i+2])...)
+	}
+	for _, p := range append(samples, path[len(path)-1]) {
+		for _, obstacle := range obstacles {
+			if !obstacle.Contains(p) {
+				continue
+			}
+			depth := math.Inf(1)
+			for _, edge := range obstacle.edges() {
+				depth = min(depth, pointSegmentDistance(p, edge.start, edge.end))
+			}
+			if depth > 1e-7 {
+				return false
+			}
+		}
+	}
+	return true
+}
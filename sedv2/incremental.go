@@ -0,0 +1,210 @@
+package sedv2
+
+import (
+	"maps"
+	"math"
+	"slices"
+)
+
+// withObstacle returns a copy of the scene with the obstacle inserted at the
+// given index of its obstacles. Only the edges the obstacle blocks are dropped
+// and only the edges of its own vertices are computed, by sweeping around each
+// of them. The scene itself is left unchanged, as are the visibility graphs
+// sharing its edges. Scenes merging overlapping obstacles cannot be updated,
+// as the obstacle may change the ones it overlaps.
+func (s *Scene) withObstacle(index int, obstacle Obstacle) *Scene {
+	scene := s.clone()
+	scene.obstacles = slices.Insert(scene.obstacles, index, obstacle)
+	maps.Copy(scene.vertexInfo, makeVertexInfoMap([]Obstacle{obstacle}))
+
+	box := newBoundingBox(obstacle.vertices())
+	blocking := []Obstacle{obstacle}
+	scene.deleteEdges(func(v, w Point) bool {
+		return newBoundingBox([]Point{v, w}).overlaps(box) && !isSegmentFree(v, w, blocking)
+	})
+
+	vertices := obstacle.vertices()
+	visible := make([][]Point, len(vertices))
+	scene.parallelFor(len(vertices), func(index int) {
+		visible[index] = scene.visibleVertices(vertices[index])
+	})
+	for i, W := range visible {
+		for _, w := range W {
+			scene.addEdge(vertices[i], w)
+		}
+	}
+	for _, edge := range obstacle.edges() {
+		if scene.keepsEdge(edge.start, edge.end) {
+			scene.addEdge(edge.start, edge.end)
+		}
+	}
+
+	return scene
+}
+
+// withoutObstacle returns a copy of the scene with the obstacle at the given
+// index removed, together with its vertices' edges. Of the pairs of remaining
+// vertices only the ones the obstacle blocked are looked at again: those of
+// every vertex are found in a grid of the vertices, among the cells lying in
+// the shadow the obstacle's bounding box casts from it.
+func (s *Scene) withoutObstacle(index int) *Scene {
+	scene := s.clone()
+	obstacle := scene.obstacles[index]
+	scene.obstacles = slices.Delete(scene.obstacles, index, index+1)
+
+	removed := obstacle.vertices()
+	for _, v := range removed {
+		delete(scene.vertexInfo, v)
+	}
+	for _, v := range removed {
+		for _, w := range scene.edges[v] {
+			if !slices.Contains(removed, w) {
+				scene.deleteEdge(w, v)
+			}
+		}
+		delete(scene.edges, v)
+	}
+
+	var vertices []Point
+	for _, o := range scene.obstacles {
+		vertices = append(vertices, o.vertices()...)
+	}
+	box := newBoundingBox(removed)
+	layout, ok := newGridLayout(newBoundingBox(append(slices.Clone(vertices), removed...)), len(vertices))
+	if !ok {
+		return scene
+	}
+	cells := make([][]int, layout.columns*layout.rows)
+	for i, v := range vertices {
+		cells[layout.cell(v)] = append(cells[layout.cell(v)], i)
+	}
+
+	blocking := []Obstacle{obstacle}
+	restored := make([][]Point, len(vertices))
+	scene.parallelFor(len(vertices), func(i int) {
+		v := vertices[i]
+		check := func(j int) {
+			w := vertices[j]
+			if j > i && newBoundingBox([]Point{v, w}).overlaps(box) && !isSegmentFree(v, w, blocking) &&
+				!slices.Contains(scene.edges[v], w) && scene.isVisiblePair(v, w) {
+				restored[i] = append(restored[i], w)
+			}
+		}
+
+		if minDistance(v, box) == 0 {
+			for j := range vertices {
+				check(j)
+			}
+			return
+		}
+		for cell := range layout.rasterize(shadow(v, box, layout.box), false) {
+			for _, j := range cells[cell] {
+				check(j)
+			}
+		}
+	})
+	for i, W := range restored {
+		slices.SortFunc(W, comparePoints)
+		for _, w := range W {
+			scene.addEdge(vertices[i], w)
+		}
+	}
+
+	return scene
+}
+
+// shadow returns a polygon covering the points w outside the box such that
+// the segment from p, which lies outside the box as well, to w meets the box,
+// as far as they lie within bounds.
+func shadow(p Point, box, bounds boundingBox) []Point {
+	center := Point{(box.min.X + box.max.X) / 2, (box.min.Y + box.max.Y) / 2}
+	reference := math.Atan2(center.Y-p.Y, center.X-p.X)
+
+	// The box lies within a half plane from p, so the angles of its corners
+	// relative to its center span less than a half turn
+	lo, hi := 0.0, 0.0
+	for _, corner := range []Point{box.min, {box.max.X, box.min.Y}, box.max, {box.min.X, box.max.Y}} {
+		angle := math.Remainder(math.Atan2(corner.Y-p.Y, corner.X-p.X)-reference, 2*math.Pi)
+		lo, hi = min(lo, angle), max(hi, angle)
+	}
+
+	// Far points at most a quarter turn apart, so that the chords between
+	// them stay outside the bounds
+	radius := 2 * (maxDistance(p, bounds) + 1)
+	polygon := []Point{p}
+	for _, angle := range []float64{lo, (lo + hi) / 2, hi} {
+		polygon = append(polygon, Point{p.X + radius*math.Cos(reference+angle), p.Y + radius*math.Sin(reference+angle)})
+	}
+	return polygon
+}
+
+// isVisiblePair reports whether the edge between the obstacle vertices u and v
+// belongs to the scene's graph: it is an obstacle edge, or a segment that
+// avoids the obstacles, and the options keep it.
+func (s *Scene) isVisiblePair(u, v Point) bool {
+	if info := s.vertexInfo[u]; info.prev == v || info.next == v {
+		return s.keepsEdge(u, v)
+	}
+	return isSegmentFree(u, v, s.obstacles) &&
+		!entersObstacle(u, v, s.vertexInfo) && !entersObstacle(v, u, s.vertexInfo) &&
+		s.keepsEdge(u, v)
+}
+
+// clone returns a copy of the scene that can be changed without affecting it.
+// The adjacency lists are shared until the copy changes them, see own.
+func (s *Scene) clone() *Scene {
+	return &Scene{
+		obstacles:  slices.Clone(s.obstacles),
+		options:    s.options,
+		vertexInfo: maps.Clone(s.vertexInfo),
+		edges:      maps.Clone(s.edges),
+		owned:      make(map[Point]bool),
+	}
+}
+
+// own makes the adjacency list of v the scene's own before it is changed.
+func (s *Scene) own(v Point) {
+	if s.owned != nil && !s.owned[v] {
+		s.edges[v] = slices.Clone(s.edges[v])
+		s.owned[v] = true
+	}
+}
+
+// deleteEdges deletes the edges between v and w for which drop reports true,
+// and the vertices left without edges.
+func (s *Scene) deleteEdges(drop func(v, w Point) bool) {
+	for v, neighbors := range s.edges {
+		if !slices.ContainsFunc(neighbors, func(w Point) bool { return drop(v, w) }) {
+			continue
+		}
+		s.own(v)
+		s.edges[v] = slices.DeleteFunc(s.edges[v], func(w Point) bool {
+			return drop(v, w)
+		})
+		if len(s.edges[v]) == 0 {
+			delete(s.edges, v)
+		}
+	}
+}
+
+// deleteEdge deletes w from the neighbours of v, and v if it is left without
+// edges.
+func (s *Scene) deleteEdge(v, w Point) {
+	s.own(v)
+	s.edges[v] = slices.DeleteFunc(s.edges[v], func(u Point) bool {
+		return u == w
+	})
+	if len(s.edges[v]) == 0 {
+		delete(s.edges, v)
+	}
+}
+
+func (s *Scene) addEdge(v, w Point) {
+	if slices.Contains(s.edges[v], w) {
+		return
+	}
+	s.own(v)
+	s.own(w)
+	s.edges[v] = append(s.edges[v], w)
+	s.edges[w] = append(s.edges[w], v)
+}
@@ -0,0 +1,102 @@
+package sedv2
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestIncrementalSceneMatchesRebuild(t *testing.T) {
+	for _, options := range []GraphOptions{{}, {Reduced: true}} {
+		name := "full"
+		if options.Reduced {
+			name = "reduced"
+		}
+		t.Run(name, func(t *testing.T) {
+			for seed := uint64(0); seed < 5; seed++ {
+				r := rand.New(rand.NewPCG(seed, 0))
+				m := NewMap(Point{}, Point{})
+				m.Options = options
+				if err := m.SetBoundary(Point{-20, -20}, Point{420, -20}, Point{420, 420}, Point{-20, 420}); err != nil {
+					t.Fatal(err)
+				}
+
+				// The cell of every obstacle, in the order of the map's
+				// obstacles
+				var cells []int
+				free := r.Perm(16)
+				for _, cell := range free[:6] {
+					if err := m.AddObstacles(randomCellObstacle(r, cell%4, cell/4)); err != nil {
+						t.Fatal(err)
+					}
+					cells = append(cells, cell)
+				}
+				free = free[6:]
+				m.Scene()
+
+				for step := 0; step < 20; step++ {
+					if len(free) > 0 && (len(cells) == 0 || r.IntN(2) == 0) {
+						cell := free[0]
+						free = free[1:]
+						if err := m.AddObstacles(randomCellObstacle(r, cell%4, cell/4)); err != nil {
+							t.Fatal(err)
+						}
+						cells = append(cells, cell)
+					} else {
+						i := r.IntN(len(cells))
+						if err := m.RemoveObstacle(i); err != nil {
+							t.Fatal(err)
+						}
+						free = append(free, cells[i])
+						cells = slices.Delete(cells, i, i+1)
+					}
+					if m.scene == nil {
+						t.Fatalf("seed %d step %d: scene rebuilt instead of updated", seed, step)
+					}
+					checkSameEdges(t, fmt.Sprintf("seed %d step %d", seed, step), m.Scene(), PrepareScene(m.configurationSpace(), options))
+				}
+			}
+		})
+	}
+}
+
+func TestIncrementalSceneRemovesBoundary(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 0))
+	var obstacles []Obstacle
+	for cell := 0; cell < 4; cell++ {
+		obstacles = append(obstacles, randomCellObstacle(r, cell%2, cell/2))
+	}
+	boundary := Obstacle{Vertices: []Point{{-20, -20}, {220, -20}, {220, 220}, {-20, 220}}, Bounding: true}
+
+	for _, options := range []GraphOptions{{}, {Reduced: true}} {
+		scene := PrepareScene(append(slices.Clone(obstacles), boundary), options)
+		checkSameEdges(t, "without boundary", scene.withoutObstacle(len(obstacles)), PrepareScene(obstacles, options))
+		checkSameEdges(t, "with boundary", PrepareScene(obstacles, options).withObstacle(len(obstacles), boundary), scene)
+	}
+}
+
+func TestIncrementalSceneLeavesOriginalUnchanged(t *testing.T) {
+	r := rand.New(rand.NewPCG(2, 0))
+	var obstacles []Obstacle
+	for cell := 0; cell < 9; cell++ {
+		obstacles = append(obstacles, randomCellObstacle(r, cell%3, cell/3))
+	}
+
+	for _, options := range []GraphOptions{{}, {Reduced: true}} {
+		scene := PrepareScene(obstacles[:8], options)
+		before := PrepareScene(obstacles[:8], options)
+
+		added := scene.withObstacle(8, obstacles[8])
+		checkSameEdges(t, "after adding", scene, before)
+		removed := scene.withoutObstacle(4)
+		checkSameEdges(t, "after removing", scene, before)
+
+		// Changing the copies further must not reach the scene either
+		added.withoutObstacle(0)
+		removed.withObstacle(4, obstacles[4])
+		removed.addEdge(obstacles[0].Vertices[0], obstacles[8].Vertices[0])
+		checkSameEdges(t, "after changing the copies", scene, before)
+		checkSameEdges(t, "after adding", added, PrepareScene(obstacles, options))
+	}
+}
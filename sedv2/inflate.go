@@ -0,0 +1,32 @@
+package sedv2
+
+import "math"
+
+// DefaultArcSegments is the number of sides of the polygons approximating
+// circles when no other number is given.
+const DefaultArcSegments = 16
+
+// Inflate returns the obstacles covering every point within radius of o: the
+// area the centre of a disk-shaped robot of that radius cannot enter. Circles
+// are approximated by polygons with arcSegments sides circumscribing them, so
+// the approximation errs on the safe side; fewer than three sides means
+// DefaultArcSegments. A bounding obstacle shrinks the free space inside it
+// instead, as described for MinkowskiSum.
+func (o Obstacle) Inflate(radius float64, arcSegments int) []Obstacle {
+	if arcSegments < 3 {
+		arcSegments = DefaultArcSegments
+	}
+	return o.MinkowskiSum(circumscribedPolygon(radius, arcSegments))
+}
+
+// circumscribedPolygon returns the regular polygon with the given number of
+// sides circumscribing the circle of the given radius around the origin.
+func circumscribedPolygon(radius float64, sides int) []Point {
+	circumradius := radius / math.Cos(math.Pi/float64(sides))
+	polygon := make([]Point, sides)
+	for i := range polygon {
+		angle := 2 * math.Pi * float64(i) / float64(sides)
+		polygon[i] = Point{circumradius * math.Cos(angle), circumradius * math.Sin(angle)}
+	}
+	return polygon
+}
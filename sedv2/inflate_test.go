@@ -0,0 +1,121 @@
+package sedv2
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCircumscribedPolygon(t *testing.T) {
+	for _, sides := range []int{3, 4, 7, 16} {
+		polygon := circumscribedPolygon(2, sides)
+		if len(polygon) != sides || ringArea(polygon) <= 0 {
+			t.Errorf("%d sides: got %v, want a counter-clockwise polygon with %d vertices", sides, polygon, sides)
+			continue
+		}
+		for i, v := range polygon {
+			next := polygon[(i+1)%sides]
+			// Every side touches the circle at its midpoint
+			if d := pointSegmentDistance(Point{}, v, next); math.Abs(d-2) > 1e-12 {
+				t.Errorf("%d sides: side %v-%v at distance %v from the centre, want 2", sides, v, next, d)
+			}
+		}
+	}
+}
+
+// obstacleDistance returns the distance from p to the area the obstacle
+// blocks, zero inside it.
+func obstacleDistance(p Point, obstacle Obstacle) float64 {
+	if obstacle.Contains(p) {
+		return 0
+	}
+	distance := math.Inf(1)
+	for _, edge := range obstacle.edges() {
+		distance = min(distance, pointSegmentDistance(p, edge.start, edge.end))
+	}
+	return distance
+}
+
+func TestInflateCoversEveryPointWithinRadius(t *testing.T) {
+	const radius, sides = 3.0, 8
+	circumradius := radius / math.Cos(math.Pi/sides)
+	obstacles := []Obstacle{
+		{Vertices: []Point{{0, 0}, {20, 0}, {20, 6}, {6, 6}, {6, 20}, {0, 20}}},
+		{
+			Vertices: []Point{{0, 0}, {30, 0}, {30, 30}, {0, 30}},
+			Holes:    [][]Point{{{5, 5}, {5, 25}, {25, 25}, {25, 5}}},
+		},
+		{Vertices: []Point{{0, 0}, {40, 0}, {40, 40}, {0, 40}}, Bounding: true},
+	}
+	r := rand.New(rand.NewPCG(1, 0))
+	for i := 0; i < 10; i++ {
+		obstacles = append(obstacles, randomStarObstacle(r, Point{20, 20}, 5, 15))
+	}
+
+	for i, obstacle := range obstacles {
+		normalized, err := NormalizeObstacles(obstacle)
+		if err != nil {
+			t.Fatalf("obstacle %d: %v", i, err)
+		}
+		obstacle = normalized[0]
+		inflated := obstacle.Inflate(radius, sides)
+		for j := 0; j < 2000; j++ {
+			p := Point{-10 + 60*r.Float64(), -10 + 60*r.Float64()}
+			distance := obstacleDistance(p, obstacle)
+			blocked := false
+			for _, piece := range inflated {
+				blocked = blocked || piece.Contains(p)
+			}
+			switch {
+			case distance < radius*(1-1e-9) && !blocked:
+				t.Errorf("obstacle %d: %v at distance %v is not covered by %v", i, p, distance, inflated)
+			case distance > circumradius*(1+1e-9) && blocked:
+				t.Errorf("obstacle %d: %v at distance %v is covered by %v", i, p, distance, inflated)
+			}
+		}
+	}
+}
+
+func TestRobotRadiusBlocksNarrowCorridor(t *testing.T) {
+	// A wall with a gap 10 wide in its middle, which a robot too wide for the
+	// gap has to walk around
+	walls := []Obstacle{
+		{Vertices: []Point{{45, -50}, {55, -50}, {55, 45}, {45, 45}}},
+		{Vertices: []Point{{45, 55}, {55, 55}, {55, 150}, {45, 150}}},
+	}
+	S, T := Point{20, 50}, Point{80, 50}
+	for _, test := range []struct {
+		radius  float64
+		blocked bool
+	}{
+		{4, false},
+		{4.9, false},
+		{5.1, true},
+		{6, true},
+	} {
+		m := NewMap(S, T)
+		m.RobotRadius = test.radius
+		if err := m.AddObstacles(walls...); err != nil {
+			t.Fatal(err)
+		}
+		path, err := m.FindShortestPath()
+		if err != nil {
+			t.Errorf("radius %v: %v", test.radius, err)
+			continue
+		}
+		// Through the gap the path is straight, around the wall it is more
+		// than twice as long
+		if throughGap := m.Results.Length < 2*S.Distance(T); throughGap == test.blocked {
+			t.Errorf("radius %v: path %v of length %v, want blocked %v", test.radius, path, m.Results.Length, test.blocked)
+		}
+		for i := 0; i+1 < len(path); i++ {
+			for _, p := range sampleRings(0.1, path[i:i+2]) {
+				for _, wall := range walls {
+					if d := obstacleDistance(p, wall); d < test.radius*(1-1e-9) {
+						t.Errorf("radius %v: path %v passes %v from a wall at %v", test.radius, path, d, p)
+					}
+				}
+			}
+		}
+	}
+}
@@ -5,16 +5,12 @@ func isBetween(a, b, c Point) bool {
 		(a.Y <= c.Y && c.Y <= b.Y || b.Y <= c.Y && c.Y <= a.Y)
 }
 
-func crossProduct(a, b, c Point) float32 {
-	return (b.X-a.X)*(c.Y-a.Y) - (b.Y-a.Y)*(c.X-a.X)
-}
-
 func doSegmentsIntersect(p1, q1, p2, q2 Point) bool {
-	// Calculate the four cross products
-	d1 := crossProduct(p2, q2, p1)
-	d2 := crossProduct(p2, q2, q1)
-	d3 := crossProduct(p1, q1, p2)
-	d4 := crossProduct(p1, q1, q2)
+	// Calculate the four orientations
+	d1 := orientation(p2, q2, p1)
+	d2 := orientation(p2, q2, q1)
+	d3 := orientation(p1, q1, p2)
+	d4 := orientation(p1, q1, q2)
 
 	// Check if the line segments intersect
 	if d1*d2 < 0 && d3*d4 < 0 {
@@ -39,11 +35,11 @@ func doSegmentsIntersect(p1, q1, p2, q2 Point) bool {
 }
 
 func doSegmentsIntersectAlternative(p1, q1, p2, q2 Point) bool {
-	// Calculate the four cross products
-	d1 := crossProduct(p2, q2, p1)
-	d2 := crossProduct(p2, q2, q1)
-	d3 := crossProduct(p1, q1, p2)
-	d4 := crossProduct(p1, q1, q2)
+	// Calculate the four orientations
+	d1 := orientation(p2, q2, p1)
+	d2 := orientation(p2, q2, q1)
+	d3 := orientation(p1, q1, p2)
+	d4 := orientation(p1, q1, q2)
 
 	// Check if the line segments intersect
 	if d1*d2 < 0 && d3*d4 < 0 {
@@ -68,13 +64,23 @@ func doSegmentsIntersectAlternative(p1, q1, p2, q2 Point) bool {
 }
 
 func doesSegmentIntersectObstacle(a, b Point, obstacle Obstacle) bool {
-	for i := 0; i < len(obstacle.Vertices); i++ {
-		start := obstacle.Vertices[i]
-		end := obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
-		if doSegmentsIntersect(a, b, start, end) {
+	for _, edge := range obstacle.edges() {
+		if doSegmentsIntersect(a, b, edge.start, edge.end) {
 			return true
 		}
 	}
 
 	return false
 }
+
+// isSegmentFree reports whether the segment ab neither touches an obstacle edge
+// nor runs through an obstacle's interior.
+func isSegmentFree(a, b Point, S []Obstacle) bool {
+	midpoint := Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+	for _, obstacle := range S {
+		if doesSegmentIntersectObstacle(a, b, obstacle) || obstacle.Contains(midpoint) {
+			return false
+		}
+	}
+	return true
+}
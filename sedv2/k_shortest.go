@@ -0,0 +1,74 @@
+package sedv2
+
+import "slices"
+
+// KShortestPaths finds up to k loopless paths from S to T in order of length
+// with Yen's algorithm, the first of them being the shortest path. Fewer paths
+// are returned when the graph has no more. Only Path and Length of the results
+// are set. When T cannot be reached the error is a *NoPathError.
+func (vg *VisibilityGraph) KShortestPaths(k int) ([]SearchResult, error) {
+	shortest, err := vg.ShortestPath(Dijkstra)
+	if err != nil || k <= 0 {
+		return nil, err
+	}
+	paths := []SearchResult{{Path: shortest.Path, Length: pathLength(shortest.Path)}}
+
+	heuristic := func(v Point) float64 {
+		return v.Distance(vg.T)
+	}
+	var candidates []SearchResult
+	for len(paths) < k {
+		previous := paths[len(paths)-1].Path
+		for i := 0; i+1 < len(previous); i++ {
+			spur, root := previous[i], previous[:i+1]
+
+			// Leave the root only by edges no path found so far takes after
+			// it, and never return to the root
+			removedEdges := make(map[Segment]bool)
+			for _, path := range paths {
+				if len(path.Path) > i+1 && slices.Equal(path.Path[:i+1], root) {
+					removedEdges[Segment{path.Path[i], path.Path[i+1]}] = true
+				}
+			}
+			removedVertices := make(map[Point]bool)
+			for _, v := range root[:i] {
+				removedVertices[v] = true
+			}
+
+			distanceMap, predecessorMap, _ := vg.searchFrom(spur, heuristic, func(v, u Point) bool {
+				return removedVertices[u] || removedEdges[Segment{v, u}]
+			})
+			if _, reached := distanceMap[vg.T]; !reached {
+				continue
+			}
+			path := append(slices.Clone(root[:i]), vg.pathTo(predecessorMap, vg.T)...)
+			if slices.ContainsFunc(candidates, func(c SearchResult) bool { return slices.Equal(c.Path, path) }) {
+				continue
+			}
+			candidates = append(candidates, SearchResult{Path: path, Length: pathLength(path)})
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+		best := 0
+		for i, candidate := range candidates {
+			if candidate.Length < candidates[best].Length {
+				best = i
+			}
+		}
+		paths = append(paths, candidates[best])
+		candidates = slices.Delete(candidates, best, best+1)
+	}
+
+	return paths, nil
+}
+
+// pathLength returns the Euclidean length of the path.
+func pathLength(path []Point) float64 {
+	length := 0.0
+	for i := 0; i+1 < len(path); i++ {
+		length += path[i].Distance(path[i+1])
+	}
+	return length
+}
@@ -0,0 +1,74 @@
+package sedv2
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// simplePathLengths returns the lengths of every loopless path from S to T in
+// increasing order.
+func simplePathLengths(vg *VisibilityGraph) []float64 {
+	var lengths []float64
+	visited := map[Point]bool{vg.S: true}
+	var walk func(v Point, length float64)
+	walk = func(v Point, length float64) {
+		if v == vg.T {
+			lengths = append(lengths, length)
+			return
+		}
+		for _, u := range vg.Neighbors(v) {
+			if !visited[u] {
+				visited[u] = true
+				walk(u, length+v.Distance(u))
+				visited[u] = false
+			}
+		}
+	}
+	walk(vg.S, 0)
+	slices.Sort(lengths)
+	return lengths
+}
+
+func TestKShortestPathsMatchesEnumeration(t *testing.T) {
+	for seed := uint64(0); seed < 10; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		points := make([]Point, 8)
+		for i := range points {
+			points[i] = Point{math.Round(100 * r.Float64()), math.Round(100 * r.Float64())}
+		}
+		vg := NewVisibilityGraph(points[0], points[len(points)-1])
+		for i := range points {
+			for j := i + 1; j < len(points); j++ {
+				if r.IntN(2) == 0 {
+					vg.AddEdges(points[i], []Point{points[j]})
+					vg.AddEdges(points[j], []Point{points[i]})
+				}
+			}
+		}
+
+		want := simplePathLengths(&vg)
+		paths, err := vg.KShortestPaths(10)
+		if len(want) == 0 {
+			if err == nil {
+				t.Errorf("seed %d: found %d paths in a graph without any", seed, len(paths))
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		if len(paths) != min(10, len(want)) {
+			t.Fatalf("seed %d: found %d paths, want %d", seed, len(paths), min(10, len(want)))
+		}
+		for i, path := range paths {
+			if path.Path[0] != vg.S || path.Path[len(path.Path)-1] != vg.T {
+				t.Fatalf("seed %d: path %d %v does not lead from S to T", seed, i, path.Path)
+			}
+			if math.Abs(path.Length-want[i]) > 1e-9 {
+				t.Errorf("seed %d: path %d has length %v, want %v", seed, i, path.Length, want[i])
+			}
+		}
+	}
+}
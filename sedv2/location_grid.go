@@ -0,0 +1,233 @@
+package sedv2
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+type boundingBox struct {
+	min, max Point
+}
+
+func newBoundingBox(points []Point) boundingBox {
+	box := boundingBox{
+		min: Point{math.Inf(1), math.Inf(1)},
+		max: Point{math.Inf(-1), math.Inf(-1)},
+	}
+	for _, p := range points {
+		box.min.X, box.min.Y = math.Min(box.min.X, p.X), math.Min(box.min.Y, p.Y)
+		box.max.X, box.max.Y = math.Max(box.max.X, p.X), math.Max(box.max.Y, p.Y)
+	}
+	return box
+}
+
+func (b boundingBox) overlaps(other boundingBox) bool {
+	return b.min.X <= other.max.X && other.min.X <= b.max.X &&
+		b.min.Y <= other.max.Y && other.min.Y <= b.max.Y
+}
+
+func (b boundingBox) contains(p Point) bool {
+	return b.min.X <= p.X && p.X <= b.max.X && b.min.Y <= p.Y && p.Y <= b.max.Y
+}
+
+// gridLayout divides a box into equal cells, numbered row by row from its
+// lower left corner.
+type gridLayout struct {
+	box                   boundingBox
+	columns, rows         int
+	cellWidth, cellHeight float64
+}
+
+// newGridLayout divides the box into about the given number of cells, as close
+// to square as the box allows. It returns false if the box is empty.
+func newGridLayout(box boundingBox, cells int) (gridLayout, bool) {
+	width, height := box.max.X-box.min.X, box.max.Y-box.min.Y
+	if width <= 0 || height <= 0 {
+		return gridLayout{}, false
+	}
+	columns := max(1, int(math.Ceil(math.Sqrt(float64(cells)*width/height))))
+	rows := max(1, int(math.Ceil(float64(cells)/float64(columns))))
+	return gridLayout{
+		box:        box,
+		columns:    columns,
+		rows:       rows,
+		cellWidth:  width / float64(columns),
+		cellHeight: height / float64(rows),
+	}, true
+}
+
+// cell returns the cell containing q, which must lie in the box.
+func (g gridLayout) cell(q Point) int {
+	column := max(0, min(g.columns-1, int((q.X-g.box.min.X)/g.cellWidth)))
+	row := max(0, min(g.rows-1, int((q.Y-g.box.min.Y)/g.cellHeight)))
+	return row*g.columns + column
+}
+
+// locationGrid is the point location structure of a ShortestPathMap: a uniform
+// grid over the scene whose cells list the roots whose regions may contain a
+// point of the cell. A root whose visible region covers the whole cell bounds
+// the distance from S to every point of it, so the roots that cannot come
+// closer than that bound anywhere in the cell are left out. Away from the
+// obstacles a cell is thus left with one or a few candidates.
+type locationGrid struct {
+	gridLayout
+	// cells holds the candidates of every cell, row by row, ordered by the
+	// least distance from S through them to any point of the cell
+	cells [][]locationCandidate
+}
+
+type locationCandidate struct {
+	root  int
+	bound float64
+}
+
+// cellsPerRoot is the number of grid cells per root of the shortest path map.
+const cellsPerRoot = 2
+
+// newLocationGrid builds the grid over the box for the roots, which must be
+// ordered by their distance from S.
+func newLocationGrid(box boundingBox, roots []pathMapRoot, parallelFor func(n int, f func(index int))) *locationGrid {
+	layout, ok := newGridLayout(box, cellsPerRoot*len(roots))
+	if !ok {
+		return nil
+	}
+	grid := &locationGrid{gridLayout: layout}
+
+	// The cells overlapped by the region of every root, and whether they lie
+	// inside it entirely
+	covered := make([]map[int]bool, len(roots))
+	parallelFor(len(roots), func(index int) {
+		covered[index] = grid.rasterize(roots[index].region.polygon(box))
+	})
+
+	grid.cells = make([][]locationCandidate, grid.columns*grid.rows)
+	for cell := range grid.cells {
+		cellBox := grid.cellBox(cell)
+		bound := math.Inf(1)
+		for i, root := range roots {
+			if covered[i][cell] {
+				bound = min(bound, root.distance+maxDistance(root.vertex, cellBox))
+			}
+		}
+		for i, root := range roots {
+			if _, ok := covered[i][cell]; !ok {
+				continue
+			}
+			if lower := root.distance + minDistance(root.vertex, cellBox); lower <= bound {
+				grid.cells[cell] = append(grid.cells[cell], locationCandidate{i, lower})
+			}
+		}
+		slices.SortFunc(grid.cells[cell], func(a, b locationCandidate) int {
+			if a.bound != b.bound {
+				if a.bound < b.bound {
+					return -1
+				}
+				return 1
+			}
+			return a.root - b.root
+		})
+	}
+
+	return grid
+}
+
+// candidates returns the candidates of the cell containing q, or false if q
+// lies outside the grid.
+func (g *locationGrid) candidates(q Point) ([]locationCandidate, bool) {
+	if g == nil || !g.box.contains(q) {
+		return nil, false
+	}
+	return g.cells[g.cell(q)], true
+}
+
+func (g gridLayout) cellBox(cell int) boundingBox {
+	column, row := cell%g.columns, cell/g.columns
+	min := Point{g.box.min.X + float64(column)*g.cellWidth, g.box.min.Y + float64(row)*g.cellHeight}
+	return boundingBox{min, Point{min.X + g.cellWidth, min.Y + g.cellHeight}}
+}
+
+// rasterize returns the cells the polygon overlaps, true for the ones lying
+// inside it entirely if it is exact. The cells its edges pass within a small
+// margin of are taken to overlap it only partly, so that rounding errs on the
+// safe side.
+func (g gridLayout) rasterize(polygon []Point, exact bool) map[int]bool {
+	covered := make(map[int]bool)
+	margin := 1e-9 * (g.box.max.X - g.box.min.X + g.box.max.Y - g.box.min.Y)
+
+	column := func(x float64) int {
+		return max(0, min(g.columns-1, int(math.Floor((x-g.box.min.X)/g.cellWidth))))
+	}
+	row := func(y float64) int {
+		return max(0, min(g.rows-1, int(math.Floor((y-g.box.min.Y)/g.cellHeight))))
+	}
+
+	for i, a := range polygon {
+		b := polygon[(i+1)%len(polygon)]
+		lo, hi := min(a.Y, b.Y)-margin, max(a.Y, b.Y)+margin
+		if hi < g.box.min.Y || lo > g.box.max.Y {
+			continue
+		}
+		for r := row(lo); r <= row(hi); r++ {
+			// The part of the edge within the row
+			y0 := max(lo, g.box.min.Y+float64(r)*g.cellHeight)
+			y1 := min(hi, g.box.min.Y+float64(r+1)*g.cellHeight)
+			x0, x1 := a.X, b.X
+			if a.Y != b.Y {
+				at := func(y float64) float64 {
+					t := max(0, min(1, (y-a.Y)/(b.Y-a.Y)))
+					return a.X + t*(b.X-a.X)
+				}
+				x0, x1 = at(y0), at(y1)
+			}
+			if x0 > x1 {
+				x0, x1 = x1, x0
+			}
+			if x1+margin < g.box.min.X || x0-margin > g.box.max.X {
+				continue
+			}
+			for c := column(x0 - margin); c <= column(x1+margin); c++ {
+				covered[r*g.columns+c] = false
+			}
+		}
+	}
+
+	// The cells whose centres lie inside are covered entirely unless an edge
+	// passes through them
+	for r := 0; r < g.rows; r++ {
+		y := g.box.min.Y + (float64(r)+0.5)*g.cellHeight
+		var crossings []float64
+		for i, a := range polygon {
+			b := polygon[(i+1)%len(polygon)]
+			if (a.Y > y) != (b.Y > y) {
+				crossings = append(crossings, a.X+(y-a.Y)/(b.Y-a.Y)*(b.X-a.X))
+			}
+		}
+		sort.Float64s(crossings)
+		for k := 0; k+1 < len(crossings); k += 2 {
+			for c := column(crossings[k]); c <= column(crossings[k+1]); c++ {
+				x := g.box.min.X + (float64(c)+0.5)*g.cellWidth
+				cell := r*g.columns + c
+				if _, partly := covered[cell]; !partly && crossings[k] <= x && x <= crossings[k+1] {
+					covered[cell] = exact
+				}
+			}
+		}
+	}
+
+	return covered
+}
+
+// minDistance returns the distance from p to the nearest point of the box.
+func minDistance(p Point, box boundingBox) float64 {
+	dx := max(box.min.X-p.X, 0, p.X-box.max.X)
+	dy := max(box.min.Y-p.Y, 0, p.Y-box.max.Y)
+	return math.Hypot(dx, dy)
+}
+
+// maxDistance returns the distance from p to the farthest point of the box.
+func maxDistance(p Point, box boundingBox) float64 {
+	dx := max(p.X-box.min.X, box.max.X-p.X)
+	dy := max(p.Y-box.min.Y, box.max.Y-p.Y)
+	return math.Hypot(dx, dy)
+}
@@ -0,0 +1,183 @@
+package sedv2
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNotConvex is reported for a robot shape that is not convex.
+var ErrNotConvex = errors.New("sedv2: robot shape is not convex")
+
+// MinkowskiSum returns the obstacles covering the points o + s for every point
+// o of the obstacle and s of the shape, which is replaced by its convex hull
+// and must not be degenerate. Summing an obstacle with the robot's shape
+// reflected through its reference point gives the area the reference point
+// cannot enter.
+//
+// Rather than decomposing non-convex obstacles into convex parts, the sum is
+// assembled from the shape swept along every edge of the obstacle, holes
+// included, and the obstacle itself moved by a point inside the shape: any
+// point of the sum whose copy of the reflected shape misses the obstacle's
+// edges is covered by the latter.
+//
+// The sum of a convex obstacle is simply the convex hull of the sums of its
+// vertices with the shape's, which is also exact where the overlay of nearly
+// parallel edges is not.
+//
+// A bounding obstacle shrinks the free space inside it instead. Should the
+// free space split into several parts, the largest is kept, and should it
+// vanish, the result is a bounding obstacle without vertices, which blocks
+// everything. Should the overlay fail, see Union, the swept shapes and the
+// moved obstacle are returned unmerged, covering the same area.
+func (o Obstacle) MinkowskiSum(shape []Point) []Obstacle {
+	shape = convexHull(shape)
+	if !o.Bounding && len(o.Holes) == 0 && isConvex(o.Vertices) {
+		return []Obstacle{{Vertices: convexSum(o.Vertices, shape)}}
+	}
+	return o.sweptSum(shape)
+}
+
+// convexSum returns the Minkowski sum of two convex polygons, the convex hull
+// of the sums of their vertices.
+func convexSum(a, b []Point) []Point {
+	var sums []Point
+	for _, v := range a {
+		for _, p := range b {
+			sums = append(sums, Point{v.X + p.X, v.Y + p.Y})
+		}
+	}
+	return convexHull(sums)
+}
+
+// sweptSum returns the Minkowski sum of the obstacle and the convex shape,
+// assembled from the shape swept along the obstacle's edges and the obstacle
+// moved by the shape's centroid, see MinkowskiSum.
+func (o Obstacle) sweptSum(shape []Point) []Obstacle {
+	var center Point
+	for _, p := range shape {
+		center.X += p.X / float64(len(shape))
+		center.Y += p.Y / float64(len(shape))
+	}
+
+	moved := Obstacle{Vertices: slices.Clone(o.Vertices)}
+	for _, hole := range o.Holes {
+		moved.Holes = append(moved.Holes, slices.Clone(hole))
+	}
+	operands := []Obstacle{moved.Translate(center.X, center.Y)}
+	for _, edge := range o.edges() {
+		operands = append(operands, Obstacle{Vertices: sweptShape(edge, shape)})
+	}
+
+	if !o.Bounding {
+		sum, err := overlay(operands, func(covered []bool) bool {
+			return slices.Contains(covered, true)
+		})
+		if err != nil {
+			return operands
+		}
+		return sum
+	}
+
+	free, err := overlay(operands, func(covered []bool) bool {
+		return covered[0] && !slices.Contains(covered[1:], true)
+	})
+	if err != nil {
+		operands[0].Bounding = true
+		return append(operands[1:], operands[0])
+	}
+	if len(free) == 0 {
+		return []Obstacle{{Bounding: true}}
+	}
+	largest := slices.MaxFunc(free, func(a, b Obstacle) int {
+		return cmp.Compare(ringArea(a.Vertices), ringArea(b.Vertices))
+	})
+	largest.Bounding = true
+	return []Obstacle{largest}
+}
+
+// sweptShape returns the area the convex shape covers while it is moved along
+// edge: the convex hull of its copies at both endpoints. The copies at a vertex
+// are the same for both of its edges, so neighbouring areas meet exactly.
+func sweptShape(edge Segment, shape []Point) []Point {
+	var points []Point
+	for _, p := range []Point{edge.start, edge.end} {
+		for _, s := range shape {
+			points = append(points, Point{p.X + s.X, p.Y + s.Y})
+		}
+	}
+	return convexHull(points)
+}
+
+// reflectShape returns the shape reflected through the origin.
+func reflectShape(shape []Point) []Point {
+	reflected := make([]Point, len(shape))
+	for i, p := range shape {
+		reflected[i] = Point{-p.X, -p.Y}
+	}
+	return reflected
+}
+
+// checkConvex returns the normalized shape, or an error if it is not a
+// convex polygon.
+func checkConvex(shape []Point) ([]Point, error) {
+	normalized, err := NormalizeObstacles(Obstacle{Vertices: shape})
+	if err != nil {
+		return nil, err
+	}
+	vertices := normalized[0].Vertices
+	for i, v := range vertices {
+		prev, next := vertices[(i+len(vertices)-1)%len(vertices)], vertices[(i+1)%len(vertices)]
+		if orientation(prev, v, next) < 0 {
+			return nil, fmt.Errorf("%w: reflex vertex %v", ErrNotConvex, v)
+		}
+	}
+	return vertices, nil
+}
+
+// isConvex reports whether the ring turns the same way at every vertex.
+func isConvex(ring []Point) bool {
+	if len(ring) < 3 {
+		return false
+	}
+	turns := 0
+	for i, v := range ring {
+		prev, next := ring[(i+len(ring)-1)%len(ring)], ring[(i+1)%len(ring)]
+		switch o := orientation(prev, v, next); {
+		case o == 0:
+		case turns == 0:
+			turns = o
+		case o != turns:
+			return false
+		}
+	}
+	return turns != 0
+}
+
+// convexHull returns the vertices of the convex hull of the points in
+// counter-clockwise order, by Andrew's monotone chain.
+func convexHull(points []Point) []Point {
+	points = slices.Clone(points)
+	slices.SortFunc(points, comparePoints)
+	points = slices.Compact(points)
+	if len(points) < 3 {
+		return points
+	}
+
+	reversed := slices.Clone(points)
+	slices.Reverse(reversed)
+
+	var hull []Point
+	for _, chain := range [][]Point{points, reversed} {
+		start := len(hull)
+		for _, p := range chain {
+			for len(hull) >= start+2 && orientation(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+				hull = hull[:len(hull)-1]
+			}
+			hull = append(hull, p)
+		}
+		hull = hull[:len(hull)-1]
+	}
+	return hull
+}
@@ -0,0 +1,142 @@
+package sedv2
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// sumContains reports whether p lies in the Minkowski sum of the obstacle and
+// the shape, that is whether the shape reflected and moved to p meets the
+// obstacle.
+func sumContains(obstacle Obstacle, shape []Point, p Point) bool {
+	reflected := Obstacle{Vertices: reflectShape(shape)}
+	return obstaclesMeet(obstacle, reflected.Translate(p.X, p.Y))
+}
+
+// checkSumMembership compares the sum of the obstacle and the shape at random
+// points around it with sumContains, skipping points too close to an edge of
+// the sum for its rounded crossings to decide.
+func checkSumMembership(t *testing.T, name string, r *rand.Rand, obstacle Obstacle, shape []Point, sum []Obstacle) {
+	t.Helper()
+	box := newBoundingBox(obstacle.vertices())
+	for i := 0; i < 2000; i++ {
+		p := Point{
+			box.min.X - 10 + (box.max.X-box.min.X+20)*r.Float64(),
+			box.min.Y - 10 + (box.max.Y-box.min.Y+20)*r.Float64(),
+		}
+		covered, nearEdge := false, false
+		for _, piece := range sum {
+			covered = covered || piece.Contains(p)
+			for _, edge := range piece.edges() {
+				nearEdge = nearEdge || pointSegmentDistance(p, edge.start, edge.end) < 1e-7
+			}
+		}
+		if want := sumContains(obstacle, shape, p); !nearEdge && covered != want {
+			t.Errorf("%s: %v covered %v by the sum %v, want %v", name, p, covered, sum, want)
+		}
+	}
+}
+
+// TestSweptSumMatchesConvexSum checks the sum assembled from swept edges, which
+// MinkowskiSum uses for obstacles that are not convex, against the hull of the
+// vertex sums it uses for convex ones.
+func TestSweptSumMatchesConvexSum(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 0))
+	randomConvex := func(center Point, size float64) []Point {
+		for {
+			var points []Point
+			for i := 0; i < 3+r.IntN(6); i++ {
+				points = append(points, Point{center.X + size*r.Float64(), center.Y + size*r.Float64()})
+			}
+			if hull := convexHull(points); len(hull) >= 3 {
+				return hull
+			}
+		}
+	}
+	for i := 0; i < 50; i++ {
+		obstacle := Obstacle{Vertices: randomConvex(Point{0, 0}, 20)}
+		shape := randomConvex(Point{-3, -3}, 6)
+		want := convexSum(obstacle.Vertices, shape)
+		if sum := obstacle.MinkowskiSum(shape); len(sum) != 1 || !slices.Equal(sum[0].Vertices, want) {
+			t.Errorf("case %d: MinkowskiSum = %v, want the hull of the vertex sums %v", i, sum, want)
+		}
+
+		swept := obstacle.sweptSum(shape)
+		if len(swept) != 1 || len(swept[0].Holes) != 0 || math.Abs(ringArea(swept[0].Vertices)-ringArea(want)) > 1e-9*ringArea(want) {
+			t.Errorf("case %d: swept sum %v, want the hull of the vertex sums %v", i, swept, want)
+		}
+		checkSumMembership(t, "convex", r, obstacle, shape, swept)
+	}
+}
+
+func TestMinkowskiSumOfLShape(t *testing.T) {
+	r := rand.New(rand.NewPCG(2, 0))
+	l := Obstacle{Vertices: []Point{{0, 0}, {20, 0}, {20, 6}, {6, 6}, {6, 20}, {0, 20}}}
+	for _, shape := range [][]Point{
+		{{-1, -2}, {3, -2}, {3, 1}, {-1, 1}},
+		{{0, 0}, {4, 1}, {1, 3}},
+		circumscribedPolygon(2, 8),
+	} {
+		sum := l.MinkowskiSum(shape)
+		checkSumMembership(t, "L", r, l, shape, sum)
+	}
+
+	// The reflected shape reaches 15 to the left, across the notch of the L,
+	// which is 14 wide, so the notch fills up
+	sum := l.MinkowskiSum([]Point{{0, 0}, {15, 0}, {15, 1}, {0, 1}})
+	if len(sum) != 1 || len(sum[0].Holes) != 0 || !sum[0].Contains(Point{13, 13}) {
+		t.Errorf("sum with a wide shape %v, want the notch covered", sum)
+	}
+}
+
+func TestCheckConvex(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		shape []Point
+		want  []Point
+		err   error
+	}{
+		{"counter-clockwise", []Point{{0, 0}, {2, 0}, {2, 1}, {0, 1}}, []Point{{0, 0}, {2, 0}, {2, 1}, {0, 1}}, nil},
+		{"clockwise", []Point{{0, 1}, {2, 1}, {2, 0}, {0, 0}}, []Point{{0, 0}, {2, 0}, {2, 1}, {0, 1}}, nil},
+		{"collinear vertex", []Point{{0, 0}, {1, 0}, {2, 0}, {1, 1}}, []Point{{0, 0}, {1, 0}, {2, 0}, {1, 1}}, nil},
+		{"L", []Point{{0, 0}, {2, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 2}}, nil, ErrNotConvex},
+		{"bow tie", []Point{{0, 0}, {2, 2}, {2, 0}, {0, 2}}, nil, ErrSelfIntersecting},
+		{"segment", []Point{{0, 0}, {1, 1}}, nil, ErrTooFewVertices},
+	} {
+		got, err := checkConvex(test.shape)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: checkConvex = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestConvexHull(t *testing.T) {
+	for _, test := range []struct {
+		name         string
+		points, want []Point
+	}{
+		{"square", []Point{{1, 1}, {0, 0}, {1, 0}, {0, 1}}, []Point{{0, 0}, {1, 0}, {1, 1}, {0, 1}}},
+		{"interior point", []Point{{0, 0}, {4, 0}, {2, 1}, {2, 4}}, []Point{{0, 0}, {4, 0}, {2, 4}}},
+		{"collinear on the hull", []Point{{0, 0}, {2, 0}, {4, 0}, {2, 4}}, []Point{{0, 0}, {4, 0}, {2, 4}}},
+		{"repeated points", []Point{{0, 0}, {4, 0}, {0, 0}, {2, 4}, {4, 0}}, []Point{{0, 0}, {4, 0}, {2, 4}}},
+		{"two points", []Point{{1, 1}, {0, 0}, {1, 1}}, []Point{{0, 0}, {1, 1}}},
+	} {
+		if got := convexHull(test.points); !slices.Equal(got, test.want) {
+			t.Errorf("%s: convexHull = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReflectShape(t *testing.T) {
+	shape := []Point{{0, 0}, {3, -1}, {2, 4}}
+	want := []Point{{0, 0}, {-3, 1}, {-2, -4}}
+	if got := reflectShape(shape); !slices.Equal(got, want) || ringArea(got) != ringArea(shape) {
+		t.Errorf("reflectShape = %v, want %v", got, want)
+	}
+}
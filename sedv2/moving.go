@@ -0,0 +1,392 @@
+package sedv2
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// DefaultWaitTime is how long the agent waits in place at a time when no other
+// time is given.
+const DefaultWaitTime = 1.0
+
+var (
+	// ErrInvalidSpeed is reported when a timed path is planned without a
+	// positive maximum speed.
+	ErrInvalidSpeed = errors.New("sedv2: maximum speed must be positive")
+	// ErrEmptyTrajectory is reported for a moving obstacle whose trajectory is
+	// given but has no points.
+	ErrEmptyTrajectory = errors.New("sedv2: trajectory has no points")
+	// ErrTrajectoryTime is reported for a trajectory time that is not finite
+	// or not later than the one before it.
+	ErrTrajectoryTime = errors.New("sedv2: trajectory times must be finite and strictly increasing")
+)
+
+// TrajectoryError describes why the trajectory of a moving obstacle was
+// rejected. Index is the position of the obstacle in the call that added it
+// and Point the position of the offending point in its trajectory, 0 for an
+// empty one.
+type TrajectoryError struct {
+	Index int
+	Point int
+	Err   error
+}
+
+func (e *TrajectoryError) Error() string {
+	return fmt.Sprintf("moving obstacle %d, trajectory point %d: %v", e.Index, e.Point, e.Err)
+}
+
+func (e *TrajectoryError) Unwrap() error {
+	return e.Err
+}
+
+// TimedPoint is a point together with the time it is reached at.
+type TimedPoint struct {
+	Point Point
+	Time  float64
+}
+
+// MovingObstacle is an obstacle translating over time. Without a Trajectory it
+// moves at the constant Velocity from its Vertices at time 0. A Trajectory
+// gives its offset from its Vertices at the listed times, in increasing order,
+// moving linearly in between and standing still before the first and after the
+// last.
+type MovingObstacle struct {
+	Obstacle
+	Velocity   Point
+	Trajectory []TimedPoint
+}
+
+// checkTrajectory reports the first problem with the obstacle's trajectory,
+// its Index left to the caller.
+func (o MovingObstacle) checkTrajectory() *TrajectoryError {
+	if o.Trajectory != nil && len(o.Trajectory) == 0 {
+		return &TrajectoryError{Err: ErrEmptyTrajectory}
+	}
+	for i, p := range o.Trajectory {
+		if math.IsNaN(p.Time) || math.IsInf(p.Time, 0) || i > 0 && p.Time <= o.Trajectory[i-1].Time {
+			return &TrajectoryError{Point: i, Err: ErrTrajectoryTime}
+		}
+	}
+	return nil
+}
+
+// OffsetAt returns how far the obstacle has moved from its Vertices at time t.
+func (o MovingObstacle) OffsetAt(t float64) Point {
+	if len(o.Trajectory) == 0 {
+		return Point{o.Velocity.X * t, o.Velocity.Y * t}
+	}
+	first, last := o.Trajectory[0], o.Trajectory[len(o.Trajectory)-1]
+	if t <= first.Time {
+		return first.Point
+	}
+	if t >= last.Time {
+		return last.Point
+	}
+	i, _ := slices.BinarySearchFunc(o.Trajectory, t, func(p TimedPoint, t float64) int {
+		return cmp.Compare(p.Time, t)
+	})
+	a, b := o.Trajectory[i-1], o.Trajectory[i]
+	s := (t - a.Time) / (b.Time - a.Time)
+	return Point{a.Point.X + s*(b.Point.X-a.Point.X), a.Point.Y + s*(b.Point.Y-a.Point.Y)}
+}
+
+// At returns a copy of the obstacle where it is at time t.
+func (o MovingObstacle) At(t float64) Obstacle {
+	offset := o.OffsetAt(t)
+	return o.Obstacle.clone().Translate(offset.X, offset.Y)
+}
+
+// collides reports whether an agent moving linearly from a at time t0 to b at
+// time t1 touches the obstacle. Between the times the trajectory bends at, the
+// agent moves linearly relative to the obstacle, so the check reduces to
+// testing segments against the obstacle at rest.
+func (o MovingObstacle) collides(a, b Point, t0, t1 float64) bool {
+	if t0 == t1 {
+		offset := o.OffsetAt(t0)
+		return o.Obstacle.Contains(Point{a.X - offset.X, a.Y - offset.Y})
+	}
+
+	times := []float64{t0}
+	for _, p := range o.Trajectory {
+		if t0 < p.Time && p.Time < t1 {
+			times = append(times, p.Time)
+		}
+	}
+	times = append(times, t1)
+
+	relative := func(t float64) Point {
+		s := (t - t0) / (t1 - t0)
+		offset := o.OffsetAt(t)
+		return Point{a.X + s*(b.X-a.X) - offset.X, a.Y + s*(b.Y-a.Y) - offset.Y}
+	}
+	for i := 0; i+1 < len(times); i++ {
+		start, end := relative(times[i]), relative(times[i+1])
+		if start == end {
+			if o.Obstacle.Contains(start) {
+				return true
+			}
+		} else if !isSegmentFree(start, end, []Obstacle{o.Obstacle}) {
+			return true
+		}
+	}
+	return false
+}
+
+// TimedPath is a path with the time each of its points is reached at. Two
+// consecutive points at the same place mean waiting there.
+type TimedPath struct {
+	Points   []TimedPoint
+	Duration float64
+}
+
+// AddMovingObstacles validates the moving obstacles' outlines like those of
+// obstacles, and their trajectories, and adds them to the map. If any of them
+// is invalid nothing is added and every problem is reported, each one an
+// *ObstacleError or a *TrajectoryError. They are only avoided by
+// FindTimedPath.
+func (m *Map) AddMovingObstacles(obstacles ...MovingObstacle) error {
+	normalized := make([]MovingObstacle, len(obstacles))
+	var errs []error
+	for i, obstacle := range obstacles {
+		var obstacleErrs []error
+		normalized[i] = obstacle
+		if obstacle.Bounding {
+			errs = append(errs, &ObstacleError{Index: i, Vertices: obstacle.Vertices, Err: ErrBoundingObstacle})
+		}
+		normalized[i].Obstacle, obstacleErrs = obstacle.Obstacle.normalize()
+		for _, err := range obstacleErrs {
+			err.(*ObstacleError).Index = i
+			errs = append(errs, err)
+		}
+		if err := obstacle.checkTrajectory(); err != nil {
+			err.Index = i
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	m.movingObstacles = append(m.movingObstacles, normalized...)
+	return nil
+}
+
+// MovingObstacles returns the moving obstacles added to the map.
+func (m *Map) MovingObstacles() []MovingObstacle {
+	return m.movingObstacles
+}
+
+func (m *Map) ClearMovingObstacles() {
+	m.movingObstacles = nil
+}
+
+// FindTimedPath finds a path from S, left at time 0, to T that an agent moving
+// at most MaxSpeed can follow without touching the map's obstacles or its
+// moving obstacles, and after which it can stay at T while the moving
+// obstacles go on. The agent moves at full speed between the vertices of the
+// Scene's visibility graph and the places just outside the moving obstacles'
+// vertices at time 0 and where their trajectories end, and may wait in place
+// for WaitTime, DefaultWaitTime if zero, at a time. The search gives up at
+// TimeHorizon or, if that is zero, once twice the time of the shortest path
+// has passed after the last trajectory ends; the error then wraps ErrNoPath.
+// Results.Path is set to the places the path visits.
+//
+// Every move of a path found is checked exactly against the moving obstacles,
+// up to TimeHorizon for obstacles that never stop. The search itself is
+// approximate, though: the extra places only follow the obstacles' vertices at
+// time 0 and at rest, waits come in whole WaitTime steps, and of the states at
+// the same place within the same WaitTime interval only the first one reached
+// is expanded. A path that needs to bend elsewhere or to slip through a gap
+// shorter than WaitTime may thus be missed, and the arrival time found is not
+// always the earliest possible.
+func (m *Map) FindTimedPath() (TimedPath, error) {
+	m.Results = Results{}
+	if !(m.MaxSpeed > 0) {
+		return TimedPath{}, fmt.Errorf("%w: %v", ErrInvalidSpeed, m.MaxSpeed)
+	}
+	if err := m.checkEndpoints(); err != nil {
+		return TimedPath{}, err
+	}
+
+	planner := m.timedPlanner()
+	shortest, err := planner.graph.ShortestPath(AStar)
+	if err != nil {
+		return TimedPath{}, err
+	}
+	horizon := m.TimeHorizon
+	if horizon <= 0 {
+		horizon = 2 * shortest.Length / m.MaxSpeed
+		for _, obstacle := range planner.moving {
+			if n := len(obstacle.Trajectory); n > 0 {
+				horizon = max(horizon, obstacle.Trajectory[n-1].Time+2*shortest.Length/m.MaxSpeed)
+			}
+		}
+	}
+
+	path, err := planner.search(horizon)
+	m.Results.Path = make([]Point, len(path.Points))
+	for i, p := range path.Points {
+		m.Results.Path[i] = p.Point
+	}
+	m.Results.Length = pathLength(m.Results.Path)
+	return path, err
+}
+
+// timedPlanner searches space and time for a path from S to T of the graph.
+type timedPlanner struct {
+	graph  VisibilityGraph
+	moving []MovingObstacle
+	speed  float64
+	wait   float64
+	// rest is the time from which on every moving obstacle stands still,
+	// infinite if one never stops
+	rest float64
+}
+
+// timedState is the agent being at a place at a time, reached from the state
+// at index parent, -1 for the start.
+type timedState struct {
+	TimedPoint
+	parent int
+}
+
+func (m *Map) timedPlanner() *timedPlanner {
+	scene := m.Scene()
+	planner := &timedPlanner{
+		graph: scene.VisibilityGraph(m.S, m.T),
+		speed: m.MaxSpeed,
+		wait:  m.WaitTime,
+	}
+	if planner.wait <= 0 {
+		planner.wait = DefaultWaitTime
+	}
+
+	// The moving obstacles are grown for the robot like the static ones, and
+	// the places their vertices start from and come to rest at become
+	// vertices of the graph, so that paths can follow in their wake and go
+	// around them once they stopped. The places are moved slightly away from
+	// the obstacle's centre, so that paths between them pass outside it
+	var places []Point
+	for _, obstacle := range m.movingObstacles {
+		offsets := []Point{obstacle.OffsetAt(0)}
+		if n := len(obstacle.Trajectory); n > 0 {
+			offsets = append(offsets, obstacle.Trajectory[n-1].Point)
+			planner.rest = max(planner.rest, obstacle.Trajectory[n-1].Time)
+		} else if obstacle.Velocity != (Point{}) {
+			planner.rest = math.Inf(1)
+		}
+		for _, piece := range m.grow(obstacle.Obstacle) {
+			planner.moving = append(planner.moving, MovingObstacle{piece, obstacle.Velocity, obstacle.Trajectory})
+			vertices := piece.vertices()
+			var center Point
+			for _, v := range vertices {
+				center.X += v.X / float64(len(vertices))
+				center.Y += v.Y / float64(len(vertices))
+			}
+			for _, offset := range offsets {
+				for _, v := range vertices {
+					v = Point{center.X + (v.X-center.X)*(1+1e-6), center.Y + (v.Y-center.Y)*(1+1e-6)}
+					places = append(places, Point{v.X + offset.X, v.Y + offset.Y})
+				}
+			}
+		}
+	}
+	slices.SortFunc(places, comparePoints)
+	places = slices.DeleteFunc(slices.Compact(places), func(p Point) bool {
+		return p == m.S || p == m.T || len(planner.graph.Neighbors(p)) > 0 ||
+			slices.ContainsFunc(scene.obstacles, func(o Obstacle) bool { return o.Contains(p) })
+	})
+
+	for _, p := range places {
+		scene.connect(&planner.graph, p)
+	}
+	others := append([]Point{m.S, m.T}, places...)
+	for i, p := range places {
+		for _, q := range others[:i+2] {
+			if isSegmentFree(p, q, scene.obstacles) {
+				planner.graph.AddEdges(p, []Point{q})
+				planner.graph.AddEdges(q, []Point{p})
+			}
+		}
+	}
+	return planner
+}
+
+// search runs A* over the agent's states, guided by the time it takes to reach
+// T at full speed. States at the same place in the same WaitTime interval are
+// expanded once.
+func (p *timedPlanner) search(horizon float64) (TimedPath, error) {
+	start, target := p.graph.S, p.graph.T
+	if p.collides(start, start, 0, 0) {
+		return TimedPath{}, fmt.Errorf("%w: S is inside a moving obstacle at time 0", ErrNoPath)
+	}
+
+	type stateKey struct {
+		point    Point
+		interval int
+	}
+	states := []timedState{{TimedPoint{start, 0}, -1}}
+	expanded := make(map[stateKey]bool)
+	pq := NewPriorityQueue()
+	pq.PushID(0, start.Distance(target)/p.speed)
+	push := func(parent int, q Point, t float64) {
+		states = append(states, timedState{TimedPoint{q, t}, parent})
+		pq.PushID(len(states)-1, t+q.Distance(target)/p.speed)
+	}
+
+	for !pq.IsEmpty() {
+		i, _ := pq.PopID()
+		state := states[i]
+		key := stateKey{state.Point, int(math.Floor(state.Time / p.wait))}
+		if expanded[key] {
+			continue
+		}
+		expanded[key] = true
+
+		if state.Point == target && !p.collides(target, target, state.Time, min(max(p.rest, state.Time), horizon)) {
+			return p.path(states, i), nil
+		}
+		if state.Time > horizon {
+			continue
+		}
+
+		if t := state.Time + p.wait; !p.collides(state.Point, state.Point, state.Time, t) {
+			push(i, state.Point, t)
+		}
+		for _, q := range p.graph.Neighbors(state.Point) {
+			t := state.Time + state.Point.Distance(q)/p.speed
+			if !p.collides(state.Point, q, state.Time, t) {
+				push(i, q, t)
+			}
+		}
+	}
+
+	return TimedPath{}, fmt.Errorf("%w: %v cannot be reached from %v before time %v", ErrNoPath, target, start, horizon)
+}
+
+func (p *timedPlanner) collides(a, b Point, t0, t1 float64) bool {
+	for _, obstacle := range p.moving {
+		if obstacle.collides(a, b, t0, t1) {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the timed path ending in the state at index i, a wait of
+// several intervals merged into one.
+func (p *timedPlanner) path(states []timedState, i int) TimedPath {
+	var points []TimedPoint
+	for ; i >= 0; i = states[i].parent {
+		n := len(points)
+		if n >= 2 && points[n-1].Point == states[i].Point && points[n-2].Point == states[i].Point {
+			points[n-1] = states[i].TimedPoint
+			continue
+		}
+		points = append(points, states[i].TimedPoint)
+	}
+	slices.Reverse(points)
+	return TimedPath{Points: points, Duration: points[len(points)-1].Time}
+}
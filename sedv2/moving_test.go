@@ -0,0 +1,165 @@
+package sedv2
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestAddMovingObstaclesRejectsBadTrajectories(t *testing.T) {
+	square := Obstacle{Vertices: []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}}
+	for _, test := range []struct {
+		name       string
+		trajectory []TimedPoint
+		point      int
+		err        error
+	}{
+		{"empty", []TimedPoint{}, 0, ErrEmptyTrajectory},
+		{"repeated time", []TimedPoint{{Time: 0}, {Point: Point{5, 0}, Time: 1}, {Point: Point{5, 5}, Time: 1}}, 2, ErrTrajectoryTime},
+		{"decreasing time", []TimedPoint{{Time: 2}, {Point: Point{5, 0}, Time: 1}}, 1, ErrTrajectoryTime},
+		{"not a number", []TimedPoint{{Time: math.NaN()}}, 0, ErrTrajectoryTime},
+		{"infinite", []TimedPoint{{Time: 0}, {Time: math.Inf(1)}}, 1, ErrTrajectoryTime},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMap(Point{}, Point{})
+			err := m.AddMovingObstacles(
+				MovingObstacle{Obstacle: square, Velocity: Point{1, 0}},
+				MovingObstacle{Obstacle: square, Trajectory: test.trajectory},
+			)
+			var trajectoryErr *TrajectoryError
+			if !errors.As(err, &trajectoryErr) {
+				t.Fatalf("got %v, want a *TrajectoryError", err)
+			}
+			if !errors.Is(err, test.err) || trajectoryErr.Index != 1 || trajectoryErr.Point != test.point {
+				t.Errorf("got %v, want %v at obstacle 1, point %d", err, test.err, test.point)
+			}
+			if len(m.MovingObstacles()) != 0 {
+				t.Errorf("added %d moving obstacles", len(m.MovingObstacles()))
+			}
+		})
+	}
+
+	m := NewMap(Point{}, Point{})
+	if err := m.AddMovingObstacles(
+		MovingObstacle{Obstacle: square, Velocity: Point{1, 0}},
+		MovingObstacle{Obstacle: square, Trajectory: []TimedPoint{{Time: -1}, {Point: Point{5, 0}, Time: 2}}},
+	); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindTimedPathWaitsForCrossingObstacle(t *testing.T) {
+	m := NewMap(Point{0, 0}, Point{100, 0})
+	m.MaxSpeed = 10
+	// Walls leave a corridor between y = -10 and 10 for x from 40 to 60
+	if err := m.AddObstacles(
+		Obstacle{Vertices: []Point{{40, 10}, {60, 10}, {60, 100}, {40, 100}}},
+		Obstacle{Vertices: []Point{{40, -100}, {60, -100}, {60, -10}, {40, -10}}},
+	); err != nil {
+		t.Fatal(err)
+	}
+	// A block as wide as the corridor moves up through it, passing from
+	// time 3 to 8, when the agent going straight would be in it
+	block := MovingObstacle{
+		Obstacle: Obstacle{Vertices: []Point{{40, -54}, {60, -54}, {60, -34}, {40, -34}}},
+		Velocity: Point{0, 8},
+	}
+	if err := m.AddMovingObstacles(block); err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := m.FindTimedPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTimedPath(t, m, path)
+
+	waits := false
+	for i := 0; i+1 < len(path.Points); i++ {
+		if path.Points[i].Point == path.Points[i+1].Point {
+			waits = true
+		}
+	}
+	// Going around the walls would take over 20
+	if !waits || path.Duration <= 10 || path.Duration > 20 {
+		t.Errorf("got %v taking %v, want it to wait for the block to pass through the corridor", path.Points, path.Duration)
+	}
+}
+
+// checkTimedPath checks that the path leads from S to T no faster than
+// MaxSpeed and, sampling it finely, that it never runs into the map's static
+// or moving obstacles, up to some time after its end.
+func checkTimedPath(t *testing.T, m *Map, path TimedPath) {
+	t.Helper()
+	points := path.Points
+	if points[0].Point != m.S || points[0].Time != 0 || points[len(points)-1].Point != m.T {
+		t.Fatalf("path %v does not lead from %v at time 0 to %v", points, m.S, m.T)
+	}
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		if a.Point.Distance(b.Point) > m.MaxSpeed*(b.Time-a.Time)*(1+1e-9) {
+			t.Fatalf("path %v moves faster than %v from %v to %v", points, m.MaxSpeed, a, b)
+		}
+	}
+
+	end := path.Duration + 5
+	for step := 0; step <= 20000; step++ {
+		time := end * float64(step) / 20000
+		p := MovingObstacle{Trajectory: points}.OffsetAt(time)
+		if !isPathClear([]Point{p}, m.Scene().obstacles) {
+			t.Fatalf("path %v is at %v inside an obstacle at time %v", points, p, time)
+		}
+		for _, obstacle := range m.MovingObstacles() {
+			if !isPathClear([]Point{p}, []Obstacle{obstacle.At(time)}) {
+				t.Fatalf("path %v is at %v inside moving obstacle %v at time %v", points, p, obstacle.At(time).Vertices, time)
+			}
+		}
+	}
+}
+
+func TestFindTimedPathAvoidsMovingObstacles(t *testing.T) {
+	found := 0
+	for seed := uint64(0); seed < 8; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		m := NewMap(Point{0, 0}, Point{200, 40})
+		m.MaxSpeed = 10
+		for i := 0; i < 3; i++ {
+			if err := m.AddObstacles(randomCellObstacle(r, i, 0)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Blocks crossing the way from S to T, some at a constant velocity and
+		// some along trajectories that come to rest
+		for i := 0; i < 4; i++ {
+			x := 30 + 40*float64(i) + 10*r.Float64()
+			y := -60 + 20*r.Float64()
+			block := MovingObstacle{Obstacle: Obstacle{Vertices: []Point{{x, y}, {x + 12, y}, {x + 12, y + 12}, {x, y + 12}}}}
+			if i%2 == 0 {
+				block.Velocity = Point{-2 + 4*r.Float64(), 5 + 10*r.Float64()}
+			} else {
+				block.Trajectory = []TimedPoint{
+					{Point{}, r.Float64()},
+					{Point{-10 + 20*r.Float64(), 70 + 30*r.Float64()}, 4 + 4*r.Float64()},
+					{Point{-10 + 20*r.Float64(), 130 + 30*r.Float64()}, 10 + 4*r.Float64()},
+				}
+			}
+			if err := m.AddMovingObstacles(block); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		path, err := m.FindTimedPath()
+		if errors.Is(err, ErrNoPath) {
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		found++
+		checkTimedPath(t, m, path)
+	}
+	if found < 4 {
+		t.Errorf("found paths in %d of 8 scenes", found)
+	}
+}
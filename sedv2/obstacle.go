@@ -1,22 +1,25 @@
 package sedv2
 
 import (
+	"bufio"
 	"fmt"
 	"math"
 	"math/rand"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func CreateRandomObstacle(numPoints int, minX, minY, maxX, maxY float32) Obstacle {
+func CreateRandomObstacle(numPoints int, minX, minY, maxX, maxY float64) Obstacle {
 	rand.Seed(time.Now().UnixNano())
 
 	points := make([]Point, numPoints)
 	for i := 0; i < numPoints; i++ {
 		points[i] = Point{
-			X: minX + rand.Float32()*(maxX-minX),
-			Y: minY + rand.Float32()*(maxY-minY),
+			X: minX + rand.Float64()*(maxX-minX),
+			Y: minY + rand.Float64()*(maxY-minY),
 		}
 	}
 
@@ -25,33 +28,156 @@ func CreateRandomObstacle(numPoints int, minX, minY, maxX, maxY float32) Obstacl
 		centroid.X += p.X
 		centroid.Y += p.Y
 	}
-	centroid.X /= float32(numPoints)
-	centroid.Y /= float32(numPoints)
+	centroid.X /= float64(numPoints)
+	centroid.Y /= float64(numPoints)
 
 	sort.Slice(points, func(i, j int) bool {
-		angle1 := math.Atan2(float64(points[i].Y-centroid.Y), float64(points[i].X-centroid.X))
-		angle2 := math.Atan2(float64(points[j].Y-centroid.Y), float64(points[j].X-centroid.X))
+		angle1 := math.Atan2(points[i].Y-centroid.Y, points[i].X-centroid.X)
+		angle2 := math.Atan2(points[j].Y-centroid.Y, points[j].X-centroid.X)
 		return angle1 < angle2
 	})
 
 	return Obstacle{Vertices: points}
 }
 
-func (o Obstacle) Translate(x float32, y float32) Obstacle {
-	for i := range o.Vertices {
-		o.Vertices[i].X += x
-		o.Vertices[i].Y += y
+// rings returns the vertex rings of the obstacle, its outline first and then
+// its holes.
+func (o Obstacle) rings() [][]Point {
+	return append([][]Point{o.Vertices}, o.Holes...)
+}
+
+// vertices returns the vertices of all the rings of the obstacle.
+func (o Obstacle) vertices() []Point {
+	vertices := slices.Clone(o.Vertices)
+	for _, hole := range o.Holes {
+		vertices = append(vertices, hole...)
+	}
+	return vertices
+}
+
+// edges returns the edges of all the rings of the obstacle.
+func (o Obstacle) edges() []Segment {
+	var edges []Segment
+	for _, ring := range o.rings() {
+		for i := 0; i < len(ring); i++ {
+			edges = append(edges, Segment{ring[i], ring[(i+1)%len(ring)]})
+		}
+	}
+	return edges
+}
+
+// Contains reports whether p lies in the area the obstacle blocks: inside its
+// outline and outside its holes, or the other way around for a bounding
+// obstacle. Points on the boundary may be reported either way.
+func (o Obstacle) Contains(p Point) bool {
+	inside := false
+	for _, edge := range o.edges() {
+		a, b := edge.start, edge.end
+		if (a.Y > p.Y) == (b.Y > p.Y) {
+			continue
+		}
+		if a.Y < b.Y && orientation(a, b, p) > 0 || a.Y > b.Y && orientation(a, b, p) < 0 {
+			inside = !inside
+		}
+	}
+	return inside != o.Bounding
+}
+
+// winding returns 1 when the area the obstacle blocks lies to the left of the
+// edges of the given ring, 0 being the outline and the rest the holes, and -1
+// when it lies to the right. An outline blocks its inside and a hole its
+// outside, the other way around for bounding obstacles.
+func (o Obstacle) winding(ring int) int {
+	if ringArea(o.rings()[ring]) < 0 != (o.Bounding != (ring > 0)) {
+		return -1
+	}
+	return 1
+}
+
+// clone returns a copy of the obstacle that does not share its rings.
+func (o Obstacle) clone() Obstacle {
+	o.Vertices = slices.Clone(o.Vertices)
+	o.Holes = slices.Clone(o.Holes)
+	for i, hole := range o.Holes {
+		o.Holes[i] = slices.Clone(hole)
+	}
+	return o
+}
+
+func (o Obstacle) Translate(x float64, y float64) Obstacle {
+	for _, ring := range o.rings() {
+		for i := range ring {
+			ring[i].X += x
+			ring[i].Y += y
+		}
 	}
 	return o
 }
 
+// ToString writes the obstacle in the text format ParseObstacles reads: one
+// "x,y" vertex per line, the outline first and then each hole after a line
+// holding the word hole.
 func (o Obstacle) ToString() string {
 	var sb strings.Builder
-	for i, vertex := range o.Vertices {
-		sb.WriteString(fmt.Sprintf("%f,%f", vertex.X, vertex.Y))
-		if i < len(o.Vertices)-1 {
-			sb.WriteString("\n")
+	for i, ring := range o.rings() {
+		if i > 0 {
+			sb.WriteString("\nhole")
+		}
+		for j, vertex := range ring {
+			if i > 0 || j > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(fmt.Sprintf("%f,%f", vertex.X, vertex.Y))
 		}
 	}
 	return sb.String()
 }
+
+// ParseObstacles reads obstacles written by ToString, separated by blank
+// lines. Lines that are not a pair of coordinates are skipped.
+func ParseObstacles(input string) []Obstacle {
+	var obstacles []Obstacle
+	var obstacle Obstacle
+	ring := &obstacle.Vertices
+
+	flush := func() {
+		if len(obstacle.Vertices) > 0 {
+			obstacle.Holes = slices.DeleteFunc(obstacle.Holes, func(hole []Point) bool { return len(hole) == 0 })
+			if len(obstacle.Holes) == 0 {
+				obstacle.Holes = nil
+			}
+			obstacles = append(obstacles, obstacle)
+		}
+		obstacle = Obstacle{}
+		ring = &obstacle.Vertices
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(input))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		if line == "hole" {
+			obstacle.Holes = append(obstacle.Holes, nil)
+			ring = &obstacle.Holes[len(obstacle.Holes)-1]
+			continue
+		}
+
+		coords := strings.Split(line, ",")
+		if len(coords) != 2 {
+			continue
+		}
+
+		x, err1 := strconv.ParseFloat(strings.TrimSpace(coords[0]), 64)
+		y, err2 := strconv.ParseFloat(strings.TrimSpace(coords[1]), 64)
+
+		if err1 == nil && err2 == nil {
+			*ring = append(*ring, Point{X: x, Y: y})
+		}
+	}
+	flush()
+
+	return obstacles
+}
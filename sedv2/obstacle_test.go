@@ -0,0 +1,25 @@
+package sedv2
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestObstacleToStringRoundTrip(t *testing.T) {
+	obstacles := []Obstacle{
+		{
+			Vertices: []Point{{0, 0}, {60, 0}, {60, 60}, {0, 60}},
+			Holes: [][]Point{
+				{{10, 10}, {10, 20}, {20, 20}, {20, 10}},
+				{{30.5, 30}, {30.5, 50}, {50, 50.25}},
+			},
+		},
+		{Vertices: []Point{{100, 100}, {120, 100}, {110, 115}}},
+	}
+
+	input := obstacles[0].ToString() + "\n\n" + obstacles[1].ToString()
+	got := ParseObstacles(input)
+	if !reflect.DeepEqual(got, obstacles) {
+		t.Errorf("ParseObstacles(%q) = %v, want %v", input, got, obstacles)
+	}
+}
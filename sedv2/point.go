@@ -3,28 +3,27 @@ package sedv2
 import "math"
 
 type Point struct {
-	X, Y float32
+	X, Y float64
 }
 
-func (p Point) Distance(q Point) float32 {
-	return float32(math.Sqrt(float64((p.X-q.X)*(p.X-q.X) + (p.Y-q.Y)*(p.Y-q.Y))))
+func (p Point) Distance(q Point) float64 {
+	return math.Hypot(p.X-q.X, p.Y-q.Y)
 }
 
-func (p Point) Angle(q Point) float32 {
+func (p Point) Angle(q Point) float64 {
 	dx := q.X - p.X
 	dy := q.Y - p.Y
-	angle := math.Atan2(float64(dy), float64(dx))
+	angle := math.Atan2(dy, dx)
 	if angle < 0 {
 		angle += 2 * math.Pi // Ensure the angle is in the range [0, 2π)
 	}
-	return float32(angle)
+	return angle
 }
 
 func (p Point) OnSegment(a, b Point) bool {
 	if p.X <= max(a.X, b.X) && p.X >= min(a.X, b.X) &&
 		p.Y <= max(a.Y, b.Y) && p.Y >= min(a.Y, b.Y) {
-		crossProduct := (p.Y-a.Y)*(b.X-a.X) - (p.X-a.X)*(b.Y-a.Y)
-		if crossProduct == 0 {
+		if orientation(a, b, p) == 0 {
 			return true
 		}
 	}
@@ -37,7 +36,7 @@ func (p Point) GetIntersectionWithRay(raydir Point, q Point, s Point) (Point, bo
 	qpx, qpy := q.X-p.X, q.Y-p.Y
 
 	det := rx*sy - ry*sx
-	if math.Abs(float64(det)) < 1e-10 {
+	if math.Abs(det) < 1e-10 {
 		return Point{}, false
 	}
 
@@ -53,3 +52,20 @@ func (p Point) GetIntersectionWithRay(raydir Point, q Point, s Point) (Point, bo
 
 	return Point{}, false
 }
+
+// comparePoints orders points lexicographically by X, then Y.
+func comparePoints(a, b Point) int {
+	if a.X != b.X {
+		if a.X < b.X {
+			return -1
+		}
+		return 1
+	}
+	if a.Y != b.Y {
+		if a.Y < b.Y {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
@@ -1,43 +1,222 @@
 package sedv2
 
 import (
+	"errors"
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"image/color"
+	"slices"
 )
 
 type Results struct {
 	VisibilityGraph *VisibilityGraph
 	Path            []Point
+	Length          float64
+	// Expanded is the number of vertices the search expanded.
+	Expanded int
+	// Alternatives are the paths found after Path by FindKShortestPaths, in
+	// order of length.
+	Alternatives []SearchResult
+	// Curves are the Dubins curves found by FindSmoothPath.
+	Curves []DubinsCurve
+	// AgentPaths are the paths found by FindMultiAgentPaths, by agent.
+	AgentPaths []TimedPath
+	// VisibilityPolygon is the region found by FindVisibilityPolygon.
+	VisibilityPolygon []Point
 }
 
 type Obstacle struct {
 	Vertices []Point
+	// Holes are rings of free space inside the obstacle, such as the
+	// courtyard of a building. They must not overlap each other or the
+	// outline.
+	Holes [][]Point
+	// Bounding marks the outer boundary of the environment, such as the walls
+	// of a room: the free space lies inside it and everything outside of it is
+	// blocked. Only the boundary set with SetBoundary is bounding; AddObstacles
+	// rejects obstacles marked Bounding.
+	Bounding bool
 }
 
+var (
+	// ErrOutsideBoundary is reported when S or T lies outside the map's
+	// boundary.
+	ErrOutsideBoundary = errors.New("sedv2: point outside the boundary")
+	// ErrObstacleIndex is reported for an index that names no obstacle.
+	ErrObstacleIndex = errors.New("sedv2: obstacle index out of range")
+)
+
 type Map struct {
 	obstacles []Obstacle
-	S         Point
-	T         Point
-	Results   Results
+	boundary  *Obstacle
+	// robotShape is the outline of the translating robot relative to its
+	// reference point, nil for a point or disk-shaped robot
+	robotShape []Point
+	regions    []Region
+	// movingObstacles are only avoided by FindTimedPath
+	movingObstacles []MovingObstacle
+	S               Point
+	T               Point
+	// Targets are the candidate targets FindNearestTarget chooses the nearest
+	// of, such as drop-off points.
+	Targets []Point
+	Options GraphOptions
+	Search  SearchAlgorithm
+	// RobotRadius is the radius of the disk-shaped robot the paths are planned
+	// for: the obstacles are inflated by it and the paths lead its centre.
+	// Zero plans for a point. Combined with a robot shape, it rounds the
+	// shape by the radius.
+	RobotRadius float64
+	// ArcSegments is the number of sides of the polygons approximating the
+	// rounded corners of inflated obstacles, DefaultArcSegments if zero.
+	ArcSegments int
+	// SteinerPoints is the number of points placed on every region edge where
+	// weighted paths may bend, DefaultSteinerPoints if zero.
+	SteinerPoints int
+	// TurningRadius is the minimum turning radius of the car-like vehicle
+	// FindSmoothPath plans for.
+	TurningRadius float64
+	// MaxSpeed is the highest speed of the agent FindTimedPath plans for.
+	MaxSpeed float64
+	// WaitTime is how long the agent waits in place at a time on a timed path,
+	// DefaultWaitTime if zero.
+	WaitTime float64
+	// TimeHorizon is the latest time FindTimedPath looks for a path until. If
+	// zero it is derived from the scene.
+	TimeHorizon float64
+	// RoadmapSpacing is the distance between the points sampled along the
+	// obstacle edges to build the roadmap of FindMaxClearancePath. If zero it
+	// is derived from the size of the scene, see DefaultRoadmapResolution.
+	RoadmapSpacing float64
+	// ShowAlternatives makes Draw render Results.Alternatives, each in its own
+	// color.
+	ShowAlternatives bool
+	Results          Results
+	scene            *Scene
+	sceneKey         sceneKey
+	pathMap          *ShortestPathMap
+}
+
+// sceneKey holds the settings the map's Scene was prepared with.
+type sceneKey struct {
+	options     GraphOptions
+	robotRadius float64
+	arcSegments int
+}
+
+func (p Point) toPosition() fyne.Position {
+	return fyne.NewPos(float32(p.X), float32(p.Y))
 }
 
 func NewMap(S, T Point) *Map {
-	return &Map{nil, S, T, Results{}}
+	return &Map{S: S, T: T}
+}
+
+// alternativeColors are cycled through to draw the alternative paths and the
+// paths of several agents.
+var alternativeColors = []color.Color{
+	color.RGBA{255, 0, 255, 255},
+	color.RGBA{0, 191, 255, 255},
+	color.RGBA{255, 140, 0, 255},
+	color.RGBA{128, 0, 128, 255},
+	color.RGBA{0, 128, 128, 255},
 }
 
 func (m *Map) Draw() fyne.CanvasObject {
 	objects := []fyne.CanvasObject{}
 
+	// Shade the visibility polygon, rasterized over its bounding box
+	if polygon := m.Results.VisibilityPolygon; len(polygon) > 0 {
+		region := Obstacle{Vertices: polygon}
+		box := newBoundingBox(polygon)
+		width, height := box.max.X-box.min.X, box.max.Y-box.min.Y
+		raster := canvas.NewRasterWithPixels(func(x, y, w, h int) color.Color {
+			p := Point{
+				box.min.X + (float64(x)+0.5)*width/float64(w),
+				box.min.Y + (float64(y)+0.5)*height/float64(h),
+			}
+			if region.Contains(p) {
+				return color.RGBA{255, 215, 0, 96}
+			}
+			return color.Transparent
+		})
+		raster.Move(box.min.toPosition())
+		raster.Resize(fyne.NewSize(float32(width), float32(height)))
+		objects = append(objects, raster)
+	}
+
 	// Draw obstacles
 	for _, obstacle := range m.obstacles {
-		for i := 0; i < len(obstacle.Vertices); i++ {
-			start := obstacle.Vertices[i]
-			end := obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
+		for _, edge := range obstacle.edges() {
 			line := canvas.NewLine(color.Black)
-			line.Position1 = fyne.NewPos(start.X, start.Y)
-			line.Position2 = fyne.NewPos(end.X, end.Y)
+			line.Position1 = edge.start.toPosition()
+			line.Position2 = edge.end.toPosition()
+			objects = append(objects, line)
+		}
+	}
+
+	// Draw the regions
+	for _, region := range m.regions {
+		for i := range region.Vertices {
+			line := canvas.NewLine(color.RGBA{139, 69, 19, 255})
+			line.StrokeWidth = 2
+			line.Position1 = region.Vertices[i].toPosition()
+			line.Position2 = region.Vertices[(i+1)%len(region.Vertices)].toPosition()
+			objects = append(objects, line)
+		}
+	}
+
+	// Draw the moving obstacles where they start and the way they go
+	for _, obstacle := range m.movingObstacles {
+		for _, edge := range obstacle.At(0).edges() {
+			line := canvas.NewLine(color.RGBA{220, 20, 60, 255})
+			line.Position1 = edge.start.toPosition()
+			line.Position2 = edge.end.toPosition()
+			objects = append(objects, line)
+		}
+		if len(obstacle.Trajectory) > 0 {
+			v := obstacle.Vertices[0]
+			for i := 0; i+1 < len(obstacle.Trajectory); i++ {
+				start, end := obstacle.Trajectory[i].Point, obstacle.Trajectory[i+1].Point
+				line := canvas.NewLine(color.RGBA{220, 20, 60, 128})
+				line.Position1 = Point{v.X + start.X, v.Y + start.Y}.toPosition()
+				line.Position2 = Point{v.X + end.X, v.Y + end.Y}.toPosition()
+				objects = append(objects, line)
+			}
+		}
+	}
+
+	// Draw the obstacles the robot's reference point has to avoid
+	if m.hasRobot() {
+		for _, obstacle := range m.Scene().obstacles {
+			for _, edge := range obstacle.edges() {
+				line := canvas.NewLine(color.RGBA{255, 165, 0, 255})
+				line.Position1 = edge.start.toPosition()
+				line.Position2 = edge.end.toPosition()
+				objects = append(objects, line)
+			}
+		}
+	}
+
+	if m.boundary != nil {
+		for _, edge := range m.boundary.edges() {
+			line := canvas.NewLine(color.Gray{Y: 96})
+			line.StrokeWidth = 3
+			line.Position1 = edge.start.toPosition()
+			line.Position2 = edge.end.toPosition()
+			objects = append(objects, line)
+		}
+	}
+
+	// Draw the robot at S and T
+	for _, p := range []Point{m.S, m.T} {
+		for i := range m.robotShape {
+			start, end := m.robotShape[i], m.robotShape[(i+1)%len(m.robotShape)]
+			line := canvas.NewLine(color.RGBA{0, 0, 255, 255})
+			line.Position1 = Point{p.X + start.X, p.Y + start.Y}.toPosition()
+			line.Position2 = Point{p.X + end.X, p.Y + end.Y}.toPosition()
 			objects = append(objects, line)
 		}
 	}
@@ -50,28 +229,66 @@ func (m *Map) Draw() fyne.CanvasObject {
 		{m.S, "S"},
 		{m.T, "T"},
 	}
+	for i, target := range m.Targets {
+		points = append(points, struct {
+			point Point
+			label string
+		}{target, fmt.Sprintf("T%d", i)})
+	}
 
 	for _, p := range points {
 		// Draw the circle
 		circle := canvas.NewCircle(color.RGBA{255, 0, 0, 255})
 		circle.Resize(fyne.NewSize(5, 5))
-		circle.Move(fyne.NewPos(p.point.X-2.5, p.point.Y-2.5))
+		circle.Move(p.point.toPosition().SubtractXY(2.5, 2.5))
 		objects = append(objects, circle)
 
 		// Draw the label
 		text := canvas.NewText(p.label, color.Black)
 		text.TextSize = 12
-		text.Move(fyne.NewPos(p.point.X+5, p.point.Y-6)) // Positioning the text near the point
+		text.Move(p.point.toPosition().Add(fyne.NewPos(5, -6))) // Positioning the text near the point
 		objects = append(objects, text)
 	}
 
+	if m.ShowAlternatives {
+		for k, alternative := range m.Results.Alternatives {
+			for i := 0; i < len(alternative.Path)-1; i++ {
+				line := canvas.NewLine(alternativeColors[k%len(alternativeColors)])
+				line.Position1 = alternative.Path[i].toPosition()
+				line.Position2 = alternative.Path[i+1].toPosition()
+				objects = append(objects, line)
+			}
+		}
+	}
+
 	if m.Results.Path != nil {
 		for i := 0; i < len(m.Results.Path)-1; i++ {
 			start := m.Results.Path[i]
 			end := m.Results.Path[i+1]
 			line := canvas.NewLine(color.RGBA{0, 255, 0, 255})
-			line.Position1 = fyne.NewPos(start.X, start.Y)
-			line.Position2 = fyne.NewPos(end.X, end.Y)
+			line.Position1 = start.toPosition()
+			line.Position2 = end.toPosition()
+			objects = append(objects, line)
+		}
+	}
+
+	for k, agentPath := range m.Results.AgentPaths {
+		for i := 0; i+1 < len(agentPath.Points); i++ {
+			line := canvas.NewLine(alternativeColors[k%len(alternativeColors)])
+			line.StrokeWidth = 2
+			line.Position1 = agentPath.Points[i].Point.toPosition()
+			line.Position2 = agentPath.Points[i+1].Point.toPosition()
+			objects = append(objects, line)
+		}
+	}
+
+	for _, curve := range m.Results.Curves {
+		points := curve.Points()
+		for i := 0; i+1 < len(points); i++ {
+			line := canvas.NewLine(color.RGBA{148, 0, 211, 255})
+			line.StrokeWidth = 2
+			line.Position1 = points[i].toPosition()
+			line.Position2 = points[i+1].toPosition()
 			objects = append(objects, line)
 		}
 	}
@@ -79,29 +296,298 @@ func (m *Map) Draw() fyne.CanvasObject {
 	return container.NewWithoutLayout(objects...)
 }
 
-func (m *Map) AddObstacles(obstacles ...Obstacle) {
-	m.obstacles = append(m.obstacles, obstacles...)
+// AddObstacles validates and normalizes the obstacles, see NormalizeObstacles,
+// and adds them to the map. If any of them is invalid none are added and the
+// error lists every problem found, obstacles marked Bounding included. A
+// prepared Scene is updated rather than rebuilt, dropping the edges the
+// obstacles block and sweeping around their vertices only, unless the map
+// plans for a robot or merges overlapping obstacles.
+func (m *Map) AddObstacles(obstacles ...Obstacle) error {
+	var errs []error
+	for i, obstacle := range obstacles {
+		if obstacle.Bounding {
+			errs = append(errs, &ObstacleError{Index: i, Vertices: obstacle.Vertices, Err: ErrBoundingObstacle})
+		}
+	}
+	normalized, err := NormalizeObstacles(obstacles...)
+	if err != nil || len(errs) > 0 {
+		return errors.Join(append(errs, err)...)
+	}
+	if m.scene != nil && m.isIncremental(normalized...) {
+		for i, obstacle := range normalized {
+			m.scene = m.scene.withObstacle(len(m.obstacles)+i, obstacle)
+		}
+	} else {
+		m.scene = nil
+	}
+	m.obstacles = append(m.obstacles, normalized...)
+	return nil
+}
+
+// RemoveObstacle removes the obstacle at the given index of Obstacles. A
+// prepared Scene is updated rather than rebuilt where it can be, restoring the
+// edges the obstacle blocked, see AddObstacles.
+func (m *Map) RemoveObstacle(index int) error {
+	if index < 0 || index >= len(m.obstacles) {
+		return fmt.Errorf("%w: %d of %d", ErrObstacleIndex, index, len(m.obstacles))
+	}
+	if m.scene != nil && m.isIncremental(m.obstacles[index]) {
+		m.scene = m.scene.withoutObstacle(index)
+	} else {
+		m.scene = nil
+	}
+	m.obstacles = slices.Delete(m.obstacles, index, index+1)
+	return nil
+}
+
+// Obstacles returns the obstacles added to the map.
+func (m *Map) Obstacles() []Obstacle {
+	return m.obstacles
+}
+
+// isIncremental reports whether the prepared Scene can be updated in place of
+// a rebuild when the given obstacles are added or removed: its obstacles must
+// be the map's own, neither grown for a robot, merged nor merged into the
+// boundary, and its settings current. The obstacles must not meet the
+// boundary either.
+func (m *Map) isIncremental(obstacles ...Obstacle) bool {
+	if m.hasRobot() || m.Options.MergeOverlapping || m.sceneKey != (sceneKey{m.Options, m.RobotRadius, m.ArcSegments}) {
+		return false
+	}
+	if m.boundary == nil {
+		return true
+	}
+	// Merging obstacles into the boundary drops them from the Scene
+	if len(m.scene.obstacles) != len(m.obstacles)+1 {
+		return false
+	}
+	return !slices.ContainsFunc(obstacles, func(obstacle Obstacle) bool {
+		return obstaclesMeet(obstacle, *m.boundary)
+	})
 }
 
 func (m *Map) ClearObstacles() {
 	m.obstacles = []Obstacle{}
+	m.scene = nil
+}
+
+// SetBoundary encloses the map in a polygon, the outline of the room or site
+// the paths must stay in. Its edges are treated as walls and S and T must lie
+// inside it. Obstacles crossing or touching the walls become part of them, so
+// no path squeezes between an obstacle and the wall it meets. Calling
+// SetBoundary without vertices removes the boundary. An invalid boundary is
+// reported like an invalid obstacle and leaves the map unchanged.
+func (m *Map) SetBoundary(vertices ...Point) error {
+	var boundary *Obstacle
+	if len(vertices) > 0 {
+		normalized, err := NormalizeObstacles(Obstacle{Vertices: vertices, Bounding: true})
+		if err != nil {
+			return err
+		}
+		boundary = &normalized[0]
+	}
+	m.boundary = boundary
+	m.scene = nil
+	return nil
+}
+
+// Boundary returns the vertices of the map's boundary, or nil if it has none.
+func (m *Map) Boundary() []Point {
+	if m.boundary == nil {
+		return nil
+	}
+	return m.boundary.Vertices
+}
+
+// checkInside returns an error wrapping ErrOutsideBoundary if p, named name,
+// lies outside the map's boundary, shrunk to fit the robot.
+func (m *Map) checkInside(name string, p Point) error {
+	for _, obstacle := range m.Scene().obstacles {
+		if obstacle.Bounding && obstacle.Contains(p) {
+			return fmt.Errorf("%w: %s %v", ErrOutsideBoundary, name, p)
+		}
+	}
+	return nil
+}
+
+// checkEndpoints checks S and T like checkInside.
+func (m *Map) checkEndpoints() error {
+	if err := m.checkInside("S", m.S); err != nil {
+		return err
+	}
+	return m.checkInside("T", m.T)
+}
+
+// Scene returns the prepared visibility graph of the map's obstacles. It is
+// built on first use and kept until the obstacles, Options or the robot
+// change, so moving S and T between queries only costs the sweeps around them.
+func (m *Map) Scene() *Scene {
+	key := sceneKey{m.Options, m.RobotRadius, m.ArcSegments}
+	if m.scene == nil || m.sceneKey != key {
+		m.scene = PrepareScene(m.configurationSpace(), m.Options)
+		m.sceneKey = key
+	}
+	return m.scene
+}
+
+// SetRobotShape makes the map plan for a translating robot with the given
+// convex outline, relative to the robot's reference point, which the paths
+// lead. Calling SetRobotShape without vertices plans for a point again. A
+// shape that is not convex is rejected with an error wrapping ErrNotConvex.
+func (m *Map) SetRobotShape(vertices ...Point) error {
+	var shape []Point
+	if len(vertices) > 0 {
+		var err error
+		if shape, err = checkConvex(vertices); err != nil {
+			return err
+		}
+	}
+	m.robotShape = shape
+	m.scene = nil
+	return nil
+}
+
+// RobotShape returns the outline of the robot set by SetRobotShape.
+func (m *Map) RobotShape() []Point {
+	return m.robotShape
+}
+
+func (m *Map) hasRobot() bool {
+	return m.robotShape != nil || m.RobotRadius > 0
+}
+
+// configurationSpace returns the obstacles the robot's reference point has to
+// avoid: the map's obstacles and boundary grown by the reflected robot shape
+// and inflated by RobotRadius, the obstacles that come to overlap merged. The
+// obstacles meeting the boundary are merged into it, see clipToBoundaries.
+// Obstacles that fail to merge still cover the same area and are avoided as
+// they are.
+func (m *Map) configurationSpace() []Obstacle {
+	obstacles := slices.Clone(m.obstacles)
+	if m.boundary != nil {
+		obstacles = append(obstacles, *m.boundary)
+	}
+	if !m.hasRobot() {
+		clipped, _ := clipToBoundaries(obstacles)
+		return clipped
+	}
+
+	var grown []Obstacle
+	for _, obstacle := range obstacles {
+		grown = append(grown, m.grow(obstacle)...)
+	}
+	merged, _ := UnionAll(grown...)
+	return merged
+}
+
+// grow returns the obstacles the robot's reference point has to avoid so that
+// the robot avoids the given one.
+func (m *Map) grow(obstacle Obstacle) []Obstacle {
+	pieces := []Obstacle{obstacle}
+	if m.robotShape != nil {
+		pieces = obstacle.MinkowskiSum(reflectShape(m.robotShape))
+	}
+	if m.RobotRadius > 0 {
+		var inflated []Obstacle
+		for _, piece := range pieces {
+			inflated = append(inflated, piece.Inflate(m.RobotRadius, m.ArcSegments)...)
+		}
+		pieces = inflated
+	}
+	return pieces
+}
+
+// ShortestPathMap returns the shortest path map of the map's Scene for the
+// current S. It is built on first use and kept while S and the Scene stay the
+// same, so querying many targets from the same S does not rebuild anything.
+func (m *Map) ShortestPathMap() *ShortestPathMap {
+	scene := m.Scene()
+	if m.pathMap == nil || m.pathMap.scene != scene || m.pathMap.S != m.S {
+		m.pathMap = scene.ShortestPathMap(m.S)
+	}
+	return m.pathMap
 }
 
 func (m *Map) ClearStartAndTarget() {
 	m.S = Point{}
 	m.T = Point{}
+	m.Targets = nil
 }
 
 func (m *Map) Clear() {
 	m.ClearObstacles()
+	m.ClearRegions()
+	m.ClearMovingObstacles()
+	m.boundary = nil
 	m.ClearStartAndTarget()
 	m.Results = Results{}
 }
 
-func (m *Map) FindShortestPath() []Point {
-	visibilityGraph := GetVisibilityGraph(m.obstacles, m.S, m.T)
+// FindShortestPath connects S and T to the map's Scene and searches the
+// resulting visibility graph with the map's Search algorithm for the shortest
+// path between them. If T is unreachable the returned error wraps ErrNoPath and
+// reports the connected components of S and T. If S or T lies outside the
+// map's boundary the error wraps ErrOutsideBoundary.
+func (m *Map) FindShortestPath() ([]Point, error) {
+	m.Results = Results{}
+	if err := m.checkEndpoints(); err != nil {
+		return nil, err
+	}
+
+	visibilityGraph := m.Scene().VisibilityGraph(m.S, m.T)
+	m.Results.VisibilityGraph = &visibilityGraph
+	result, err := visibilityGraph.ShortestPath(m.Search)
+	m.Results.Path = result.Path
+	m.Results.Length = result.Length
+	m.Results.Expanded = result.Expanded
+	return result.Path, err
+}
+
+// FindShortestPathTo makes q the map's T and finds the shortest path from S to
+// it with the map's ShortestPathMap. No visibility graph is built, so
+// Results.VisibilityGraph is cleared.
+func (m *Map) FindShortestPathTo(q Point) ([]Point, error) {
+	m.T = q
+	m.Results = Results{}
+	if err := m.checkEndpoints(); err != nil {
+		return nil, err
+	}
+	path, length, err := m.ShortestPathMap().ShortestPath(q)
+	m.Results = Results{Path: path, Length: length}
+	return path, err
+}
+
+// FindVisibilityPolygon finds the part of the free space visible from p, see
+// Scene.VisibilityPolygon, and sets Results.VisibilityPolygon to it. When the
+// map plans for a robot the obstacles are grown for it, so the polygon is
+// where its reference point can see. If p lies outside the map's boundary the
+// error wraps ErrOutsideBoundary.
+func (m *Map) FindVisibilityPolygon(p Point) ([]Point, error) {
+	m.Results = Results{}
+	if err := m.checkInside("point", p); err != nil {
+		return nil, err
+	}
+	m.Results.VisibilityPolygon = m.Scene().VisibilityPolygon(p)
+	return m.Results.VisibilityPolygon, nil
+}
+
+// FindKShortestPaths finds up to k paths from S to T in order of length on the
+// visibility graph of the map's Scene, see VisibilityGraph.KShortestPaths. The
+// shortest one becomes Results.Path and the others Results.Alternatives. Errors
+// are reported as by FindShortestPath.
+func (m *Map) FindKShortestPaths(k int) ([]SearchResult, error) {
+	m.Results = Results{}
+	if err := m.checkEndpoints(); err != nil {
+		return nil, err
+	}
+
+	visibilityGraph := m.Scene().VisibilityGraph(m.S, m.T)
 	m.Results.VisibilityGraph = &visibilityGraph
-	_, path := visibilityGraph.ShortestEuclideanDistance()
-	m.Results.Path = path
-	return path
+	paths, err := visibilityGraph.KShortestPaths(k)
+	if len(paths) > 0 {
+		m.Results.Path = paths[0].Path
+		m.Results.Length = paths[0].Length
+		m.Results.Alternatives = paths[1:]
+	}
+	return paths, err
 }
@@ -0,0 +1,84 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestFindShortestPathAroundObstaclesMeetingTheBoundary(t *testing.T) {
+	obstacles := map[string]Obstacle{
+		"crossing": {Vertices: []Point{{50, -10}, {60, -10}, {60, 80}, {50, 80}}},
+		"touching": {Vertices: []Point{{50, 0}, {60, 0}, {60, 80}, {50, 80}}},
+	}
+	// Over the top of the obstacle, as it leaves no gap along the wall
+	want := math.Hypot(40, 70) + 10 + math.Hypot(30, 70)
+
+	for name, obstacle := range obstacles {
+		for _, options := range []GraphOptions{
+			{Builder: LeeBuilder},
+			{Builder: RotationTreeBuilder},
+			{MergeOverlapping: true},
+			{Reduced: true},
+		} {
+			t.Run(fmt.Sprintf("%s %+v", name, options), func(t *testing.T) {
+				m := NewMap(Point{10, 10}, Point{90, 10})
+				m.Options = options
+				if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+					t.Fatal(err)
+				}
+				if err := m.AddObstacles(obstacle); err != nil {
+					t.Fatal(err)
+				}
+				path, err := m.FindShortestPath()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if math.Abs(m.Results.Length-want) > 1e-9 {
+					t.Errorf("path %v of length %v, want length %v", path, m.Results.Length, want)
+				}
+			})
+		}
+	}
+}
+
+func TestAddObstaclesMeetingTheBoundaryRebuildsScene(t *testing.T) {
+	m := NewMap(Point{10, 10}, Point{90, 10})
+	if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{20, 40}, {30, 40}, {30, 50}}}); err != nil {
+		t.Fatal(err)
+	}
+	m.Scene()
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{50, 0}, {60, 0}, {60, 80}, {50, 80}}}); err != nil {
+		t.Fatal(err)
+	}
+	checkSameEdges(t, "added", m.Scene(), PrepareScene(m.configurationSpace(), m.Options))
+	if err := m.RemoveObstacle(0); err != nil {
+		t.Fatal(err)
+	}
+	checkSameEdges(t, "removed", m.Scene(), PrepareScene(m.configurationSpace(), m.Options))
+}
+
+func TestAddObstaclesRejectsBoundingObstacles(t *testing.T) {
+	m := NewMap(Point{10, 10}, Point{90, 10})
+	room := []Point{{0, 0}, {100, 0}, {100, 100}, {0, 100}}
+	err := m.AddObstacles(
+		Obstacle{Vertices: []Point{{20, 40}, {30, 40}, {30, 50}}},
+		Obstacle{Vertices: room, Bounding: true},
+	)
+	var obstacleErr *ObstacleError
+	if !errors.As(err, &obstacleErr) || !errors.Is(err, ErrBoundingObstacle) || obstacleErr.Index != 1 {
+		t.Fatalf("got error %v, want an *ObstacleError for obstacle 1 wrapping ErrBoundingObstacle", err)
+	}
+	if len(m.Obstacles()) != 0 {
+		t.Errorf("obstacles %v added despite the error", m.Obstacles())
+	}
+
+	err = m.AddMovingObstacles(MovingObstacle{Obstacle: Obstacle{Vertices: room, Bounding: true}})
+	if !errors.Is(err, ErrBoundingObstacle) {
+		t.Errorf("got error %v from AddMovingObstacles, want ErrBoundingObstacle", err)
+	}
+}
@@ -0,0 +1,186 @@
+package sedv2
+
+import (
+	"math"
+	"math/big"
+)
+
+// ccwErrBound is the relative error bound of the floating point orientation
+// determinant (Shewchuk, "Adaptive Precision Floating-Point Arithmetic and Fast
+// Robust Geometric Predicates"). Determinants whose magnitude exceeds the bound
+// have a trustworthy sign, the rest are recomputed exactly.
+var ccwErrBound = (3 + 16*math.Pow(2, -53)) * math.Pow(2, -53)
+
+// orientation reports on which side of the directed line ab the point c lies:
+// 1 when a, b, c make a counter-clockwise turn, -1 when they make a clockwise
+// turn and 0 when they are collinear. The result is exact for any input.
+func orientation(a, b, c Point) int {
+	detLeft := (b.X - a.X) * (c.Y - a.Y)
+	detRight := (b.Y - a.Y) * (c.X - a.X)
+	det := detLeft - detRight
+
+	var detSum float64
+	switch {
+	case detLeft > 0:
+		if detRight <= 0 {
+			return sign(det)
+		}
+		detSum = detLeft + detRight
+	case detLeft < 0:
+		if detRight >= 0 {
+			return sign(det)
+		}
+		detSum = -detLeft - detRight
+	default:
+		return sign(det)
+	}
+
+	if math.Abs(det) >= ccwErrBound*detSum {
+		return sign(det)
+	}
+
+	return exactOrientation(a, b, c)
+}
+
+func exactOrientation(a, b, c Point) int {
+	ax, ay := new(big.Rat).SetFloat64(a.X), new(big.Rat).SetFloat64(a.Y)
+	bx, by := new(big.Rat).SetFloat64(b.X), new(big.Rat).SetFloat64(b.Y)
+	cx, cy := new(big.Rat).SetFloat64(c.X), new(big.Rat).SetFloat64(c.Y)
+
+	left := new(big.Rat).Mul(new(big.Rat).Sub(bx, ax), new(big.Rat).Sub(cy, ay))
+	right := new(big.Rat).Mul(new(big.Rat).Sub(by, ay), new(big.Rat).Sub(cx, ax))
+
+	return left.Cmp(right)
+}
+
+// iccErrBound is the relative error bound of the floating point incircle
+// determinant, from the same paper as ccwErrBound.
+var iccErrBound = (10 + 96*math.Pow(2, -53)) * math.Pow(2, -53)
+
+// inCircle reports where d lies relative to the circle through a, b and c,
+// which must make a counter-clockwise turn: 1 when inside it, -1 when outside
+// and 0 when on it. The result is exact for any input.
+func inCircle(a, b, c, d Point) int {
+	adx, ady := a.X-d.X, a.Y-d.Y
+	bdx, bdy := b.X-d.X, b.Y-d.Y
+	cdx, cdy := c.X-d.X, c.Y-d.Y
+
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	alift := adx*adx + ady*ady
+	blift := bdx*bdx + bdy*bdy
+	clift := cdx*cdx + cdy*cdy
+
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*alift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*blift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*clift
+	if math.Abs(det) > iccErrBound*permanent {
+		return sign(det)
+	}
+
+	return exactInCircle(a, b, c, d)
+}
+
+func exactInCircle(a, b, c, d Point) int {
+	dx, dy := new(big.Rat).SetFloat64(d.X), new(big.Rat).SetFloat64(d.Y)
+	var rows [3][3]*big.Rat
+	for i, p := range []Point{a, b, c} {
+		x := new(big.Rat).Sub(new(big.Rat).SetFloat64(p.X), dx)
+		y := new(big.Rat).Sub(new(big.Rat).SetFloat64(p.Y), dy)
+		lift := new(big.Rat).Add(new(big.Rat).Mul(x, x), new(big.Rat).Mul(y, y))
+		rows[i] = [3]*big.Rat{x, y, lift}
+	}
+
+	det := new(big.Rat)
+	for i := 0; i < 3; i++ {
+		j, k := (i+1)%3, (i+2)%3
+		minor := new(big.Rat).Sub(
+			new(big.Rat).Mul(rows[j][0], rows[k][1]),
+			new(big.Rat).Mul(rows[k][0], rows[j][1]),
+		)
+		det.Add(det, new(big.Rat).Mul(rows[i][2], minor))
+	}
+	return det.Sign()
+}
+
+func sign(x float64) int {
+	if x > 0 {
+		return 1
+	}
+	if x < 0 {
+		return -1
+	}
+	return 0
+}
+
+// compareAngle orders a and b by the counter-clockwise angle of the vectors
+// origin->a and origin->b measured from the positive x axis, in [0, 2π).
+func compareAngle(origin, a, b Point) int {
+	aHalf, bHalf := angleHalf(origin, a), angleHalf(origin, b)
+	if aHalf != bHalf {
+		if aHalf < bHalf {
+			return -1
+		}
+		return 1
+	}
+	return -orientation(origin, a, b)
+}
+
+// angleHalf returns 0 when the angle of origin->p lies in [0, π) and 1 when it
+// lies in [π, 2π).
+func angleHalf(origin, p Point) int {
+	if p.Y > origin.Y || p.Y == origin.Y && p.X > origin.X {
+		return 0
+	}
+	return 1
+}
+
+// crossesHorizontalRay reports whether the segment ab meets the ray leaving p in
+// the positive x direction anywhere other than at p itself. Segments lying on the
+// ray's supporting line are not considered crossing.
+func crossesHorizontalRay(p, a, b Point) bool {
+	lo, hi := a, b
+	if lo.Y > hi.Y {
+		lo, hi = hi, lo
+	}
+	if lo.Y > p.Y || hi.Y < p.Y || lo.Y == hi.Y {
+		return false
+	}
+	if lo.Y == p.Y {
+		return lo.X > p.X
+	}
+	if hi.Y == p.Y {
+		return hi.X > p.X
+	}
+	return orientation(lo, hi, p) > 0
+}
+
+// rayHitsSegment reports whether the ray leaving p through q meets the segment
+// ab. Segments lying on the ray's supporting line are not considered hit.
+func rayHitsSegment(p, q, a, b Point) bool {
+	aSide, bSide := orientation(p, q, a), orientation(p, q, b)
+	if aSide == bSide {
+		return false
+	}
+	if aSide == 0 {
+		return isAhead(p, q, a)
+	}
+	if bSide == 0 {
+		return isAhead(p, q, b)
+	}
+	if aSide > 0 {
+		return orientation(p, a, b) <= 0
+	}
+	return orientation(p, a, b) >= 0
+}
+
+// isAhead reports whether a, known to lie on the line through p and q, is not
+// behind p when looking from p towards q.
+func isAhead(p, q, a Point) bool {
+	if q.X != p.X {
+		return (a.X >= p.X) == (q.X > p.X)
+	}
+	return (a.Y >= p.Y) == (q.Y > p.Y)
+}
@@ -0,0 +1,90 @@
+package sedv2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestInCircle(t *testing.T) {
+	a, b, c := Point{5, 0}, Point{3, 4}, Point{-5, 0}
+	for _, test := range []struct {
+		d    Point
+		want int
+	}{
+		{Point{0, 5}, 0},
+		{Point{0, math.Nextafter(5, 0)}, 1},
+		{Point{0, math.Nextafter(5, 6)}, -1},
+		{Point{0, -5}, 0},
+		{Point{0, 0}, 1},
+	} {
+		if got := inCircle(a, b, c, test.d); got != test.want {
+			t.Errorf("inCircle(%v) = %d, want %d", test.d, got, test.want)
+		}
+	}
+}
+
+// TestOrientationNearlyCollinear probes a 64 by 64 grid of points around
+// (0.5, 0.5), one unit in the last place apart, against the line through
+// (12, 12) and (24, 24), where the plain floating point determinant often has
+// the wrong sign (Kettner et al., "Classroom Examples of Robustness Problems in
+// Geometric Computations"). The points above the diagonal lie to the left of
+// the line.
+func TestOrientationNearlyCollinear(t *testing.T) {
+	a, b := Point{12, 12}, Point{24, 24}
+	ulp := math.Nextafter(0.5, 1) - 0.5
+	wrong := 0
+	for i := 0; i < 64; i++ {
+		for j := 0; j < 64; j++ {
+			c := Point{0.5 + float64(i)*ulp, 0.5 + float64(j)*ulp}
+			want := sign(c.Y - c.X)
+			if got := orientation(a, b, c); got != want {
+				t.Errorf("orientation(%v, %v, %v) = %d, want %d", a, b, c, got, want)
+			}
+			if got := orientation(b, a, c); got != -want {
+				t.Errorf("orientation(%v, %v, %v) = %d, want %d", b, a, c, got, -want)
+			}
+			if got := orientation(c, a, b); got != want {
+				t.Errorf("orientation(%v, %v, %v) = %d, want %d", c, a, b, got, want)
+			}
+			if sign((b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X)) != want {
+				wrong++
+			}
+		}
+	}
+	if wrong == 0 {
+		t.Error("the floating point determinant has the right sign everywhere, the grid does not test the exact fallback")
+	}
+}
+
+func TestDoSegmentsIntersect(t *testing.T) {
+	above := Point{0.5, math.Nextafter(0.5, 1)}
+	below := Point{math.Nextafter(0.5, 1), 0.5}
+	for _, test := range []struct {
+		name           string
+		p1, q1, p2, q2 Point
+		want           bool
+	}{
+		{"crossing", Point{0, 0}, Point{2, 2}, Point{0, 2}, Point{2, 0}, true},
+		{"disjoint", Point{0, 0}, Point{1, 0}, Point{0, 1}, Point{1, 1}, false},
+		{"endpoint on the other's interior", Point{0, 0}, Point{2, 0}, Point{1, 0}, Point{1, 1}, true},
+		{"sharing an endpoint", Point{0, 0}, Point{1, 0}, Point{0, 0}, Point{0, 1}, false},
+		{"collinear overlapping", Point{0, 0}, Point{2, 0}, Point{1, 0}, Point{3, 0}, true},
+		{"collinear containing", Point{0, 0}, Point{3, 0}, Point{1, 0}, Point{2, 0}, true},
+		{"collinear end to end", Point{0, 0}, Point{1, 0}, Point{1, 0}, Point{2, 0}, false},
+		{"collinear apart", Point{0, 0}, Point{1, 0}, Point{2, 0}, Point{3, 0}, false},
+		{"identical", Point{0, 0}, Point{1, 1}, Point{0, 0}, Point{1, 1}, false},
+		{"nearly collinear crossing", Point{0, 0}, Point{24, 24}, above, below, true},
+		{"nearly collinear beside", Point{0, 0}, Point{24, 24}, above, Point{1.5, math.Nextafter(1.5, 2)}, false},
+		{"nearly collinear ending on", Point{0, 0}, Point{24, 24}, above, Point{0.75, 0.75}, true},
+	} {
+		for _, swapped := range []bool{false, true} {
+			p1, q1, p2, q2 := test.p1, test.q1, test.p2, test.q2
+			if swapped {
+				p1, q1, p2, q2 = p2, q2, p1, q1
+			}
+			if got := doSegmentsIntersect(p1, q1, p2, q2); got != test.want {
+				t.Errorf("%s: doSegmentsIntersect(%v, %v, %v, %v) = %v, want %v", test.name, p1, q1, p2, q2, got, test.want)
+			}
+		}
+	}
+}
@@ -4,7 +4,8 @@ import "container/heap"
 
 type Item struct {
 	point    Point
-	priority float32
+	id       int
+	priority float64
 	index    int
 }
 
@@ -47,14 +48,14 @@ func NewPriorityQueue() *PriorityQueue {
 	return pq
 }
 
-func (pq *PriorityQueue) PushPoint(point Point, priority float32) {
+func (pq *PriorityQueue) PushPoint(point Point, priority float64) {
 	heap.Push(pq, &Item{
 		point:    point,
 		priority: priority,
 	})
 }
 
-func (pq *PriorityQueue) PopPoint() (Point, float32) {
+func (pq *PriorityQueue) PopPoint() (Point, float64) {
 	item := heap.Pop(pq).(*Item)
 	return item.point, item.priority
 }
@@ -62,3 +63,17 @@ func (pq *PriorityQueue) PopPoint() (Point, float32) {
 func (pq *PriorityQueue) IsEmpty() bool {
 	return pq.Len() == 0
 }
+
+// PushID pushes an item known by an id, such as the index of a search state
+// kept elsewhere.
+func (pq *PriorityQueue) PushID(id int, priority float64) {
+	heap.Push(pq, &Item{
+		id:       id,
+		priority: priority,
+	})
+}
+
+func (pq *PriorityQueue) PopID() (int, float64) {
+	item := heap.Pop(pq).(*Item)
+	return item.id, item.priority
+}
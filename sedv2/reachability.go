@@ -0,0 +1,73 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrNoPath is reported when the target cannot be reached from the start.
+// Errors returned by the path search wrap it in a *NoPathError carrying a
+// Reachability report, so callers can test for it with errors.Is.
+var ErrNoPath = errors.New("sedv2: no path from S to T")
+
+type NoPathError struct {
+	Reachability Reachability
+}
+
+func (e *NoPathError) Error() string {
+	return fmt.Sprintf("%v: S is in component %d, T is in component %d of %d",
+		ErrNoPath, e.Reachability.SComponent, e.Reachability.TComponent, len(e.Reachability.Components))
+}
+
+func (e *NoPathError) Unwrap() error {
+	return ErrNoPath
+}
+
+// Reachability splits the vertices of a visibility graph into connected
+// components and names the ones containing S and T. Components are numbered in
+// the order of their smallest vertex, so the numbering is stable between runs.
+type Reachability struct {
+	Components [][]Point
+	SComponent int
+	TComponent int
+}
+
+func (r Reachability) Reachable() bool {
+	return r.SComponent == r.TComponent
+}
+
+func (vg *VisibilityGraph) Reachability() Reachability {
+	vertices := append(vg.Vertices(), vg.S, vg.T)
+	slices.SortFunc(vertices, comparePoints)
+	vertices = slices.Compact(vertices)
+
+	componentOf := make(map[Point]int)
+	var components [][]Point
+	for _, v := range vertices {
+		if _, seen := componentOf[v]; seen {
+			continue
+		}
+
+		id := len(components)
+		component := []Point{v}
+		componentOf[v] = id
+		for i := 0; i < len(component); i++ {
+			for _, u := range vg.Neighbors(component[i]) {
+				if _, seen := componentOf[u]; !seen {
+					componentOf[u] = id
+					component = append(component, u)
+				}
+			}
+		}
+
+		slices.SortFunc(component, comparePoints)
+		components = append(components, component)
+	}
+
+	return Reachability{
+		Components: components,
+		SComponent: componentOf[vg.S],
+		TComponent: componentOf[vg.T],
+	}
+}
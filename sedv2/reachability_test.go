@@ -0,0 +1,75 @@
+package sedv2
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReachabilityOfEnclosedTarget(t *testing.T) {
+	frame := Obstacle{
+		Vertices: []Point{{0, 0}, {30, 0}, {30, 30}, {0, 30}},
+		Holes:    [][]Point{{{10, 10}, {10, 20}, {20, 20}, {20, 10}}},
+	}
+	S, T := Point{-10, 15}, Point{15, 15}
+	m := NewMap(S, T)
+	if err := m.AddObstacles(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Reachability{
+		// Ordered by their smallest vertex, S comes first
+		Components: [][]Point{
+			{S, {0, 0}, {0, 30}, {30, 0}, {30, 30}},
+			{{10, 10}, {10, 20}, T, {20, 10}, {20, 20}},
+		},
+		SComponent: 0,
+		TComponent: 1,
+	}
+	visibilityGraph := m.Scene().VisibilityGraph(S, T)
+	got := visibilityGraph.Reachability()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got reachability %+v, want %+v", got, want)
+	}
+	if got.Reachable() {
+		t.Error("Reachable() = true, want false")
+	}
+
+	_, err := m.FindShortestPath()
+	if !errors.Is(err, ErrNoPath) {
+		t.Fatalf("got %v, want ErrNoPath", err)
+	}
+	var noPath *NoPathError
+	if !errors.As(err, &noPath) {
+		t.Fatalf("got %T, want *NoPathError", err)
+	}
+	if !reflect.DeepEqual(noPath.Reachability, want) {
+		t.Errorf("got reachability %+v in the error, want %+v", noPath.Reachability, want)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "component 0") || !strings.Contains(msg, "component 1 of 2") {
+		t.Errorf("got message %q, want it to name components 0 and 1 of 2", msg)
+	}
+}
+
+func TestReachabilityOfReachableTarget(t *testing.T) {
+	square := Obstacle{Vertices: []Point{{0, 0}, {30, 0}, {30, 30}, {0, 30}}}
+	S, T := Point{-10, 15}, Point{40, 15}
+	m := NewMap(S, T)
+	if err := m.AddObstacles(square); err != nil {
+		t.Fatal(err)
+	}
+
+	visibilityGraph := m.Scene().VisibilityGraph(S, T)
+	got := visibilityGraph.Reachability()
+	want := Reachability{Components: [][]Point{{S, {0, 0}, {0, 30}, {30, 0}, {30, 30}, T}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got reachability %+v, want %+v", got, want)
+	}
+	if !got.Reachable() {
+		t.Error("Reachable() = false, want true")
+	}
+	if _, err := m.FindShortestPath(); err != nil {
+		t.Errorf("got %v, want a path", err)
+	}
+}
@@ -0,0 +1,51 @@
+package sedv2
+
+// vertexInfo describes an obstacle vertex by its neighbours on the obstacle
+// boundary, the winding of its ring and whether the obstacle's interior
+// angle at it is at most π.
+type vertexInfo struct {
+	prev, next Point
+	winding    int
+	convex     bool
+}
+
+func makeVertexInfoMap(S []Obstacle) map[Point]vertexInfo {
+	info := make(map[Point]vertexInfo)
+	for _, obstacle := range S {
+		for r, ring := range obstacle.rings() {
+			n := len(ring)
+			winding := obstacle.winding(r)
+			for i, vertex := range ring {
+				prev := ring[(i+n-1)%n]
+				next := ring[(i+1)%n]
+				info[vertex] = vertexInfo{
+					prev:    prev,
+					next:    next,
+					winding: winding,
+					convex:  orientation(prev, vertex, next)*winding >= 0,
+				}
+			}
+		}
+	}
+	return info
+}
+
+// isTangentEdge reports whether the edge uv belongs to the reduced visibility
+// graph, i.e. whether it is tangent at both endpoints.
+func isTangentEdge(u, v Point, vertices map[Point]vertexInfo) bool {
+	return isTangentAt(u, v, vertices) && isTangentAt(v, u, vertices)
+}
+
+// isTangentAt reports whether the line through u and v touches the obstacle of
+// v at a convex vertex without entering it. Points that are not obstacle
+// vertices, such as S and T, are tangent to everything.
+func isTangentAt(u, v Point, vertices map[Point]vertexInfo) bool {
+	info, ok := vertices[v]
+	if !ok {
+		return true
+	}
+	if !info.convex {
+		return false
+	}
+	return orientation(u, v, info.prev)*orientation(u, v, info.next) >= 0
+}
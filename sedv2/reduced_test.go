@@ -0,0 +1,127 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// shortestLength returns the length of the shortest path between start and
+// target in the scene's graph, or +Inf if there is none.
+func shortestLength(t *testing.T, scene *Scene, start, target Point) float64 {
+	t.Helper()
+	visibilityGraph := scene.VisibilityGraph(start, target)
+	result, err := visibilityGraph.ShortestPath(Dijkstra)
+	if errors.Is(err, ErrNoPath) {
+		return math.Inf(1)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return result.Length
+}
+
+func TestReducedGraphFromObstacleEdge(t *testing.T) {
+	triangle := []Obstacle{{Vertices: []Point{{2, 2}, {8, 8}, {2, 8}}}}
+	full := PrepareScene(triangle, GraphOptions{})
+	reduced := PrepareScene(triangle, GraphOptions{Reduced: true})
+
+	S := Point{5, 5}
+	for _, test := range []struct {
+		T    Point
+		want float64
+	}{
+		// Straight away from the edge S lies on
+		{Point{10, 9}, math.Hypot(5, 4)},
+		{Point{-36, -40}, math.Hypot(41, 45)},
+		// Along the edge and around its end
+		{Point{8, 12}, math.Hypot(3, 3) + 4},
+		{Point{0, 10}, math.Hypot(3, 3) + math.Hypot(2, 8)},
+	} {
+		for name, scene := range map[string]*Scene{"full": full, "reduced": reduced} {
+			if got := shortestLength(t, scene, S, test.T); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("%s graph from %v to %v: got length %v, want %v", name, S, test.T, got, test.want)
+			}
+			if got := shortestLength(t, scene, test.T, S); math.Abs(got-test.want) > 1e-9 {
+				t.Errorf("%s graph from %v to %v: got length %v, want %v", name, test.T, S, got, test.want)
+			}
+		}
+	}
+}
+
+// TestReducedGraphMatchesFullGraph compares the shortest path lengths of the
+// full and the reduced graph between free points, obstacle vertices and points
+// on obstacle edges. The paths from a point on an edge are also compared with
+// the paths from a point just off the edge.
+func TestReducedGraphMatchesFullGraph(t *testing.T) {
+	for seed := uint64(0); seed < 6; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		var obstacles []Obstacle
+		if seed%2 == 0 {
+			obstacles = randomBoxes(r, 8)
+		} else {
+			for _, cell := range r.Perm(9)[:5] {
+				obstacle := randomCellObstacle(r, cell%3, cell/3)
+				for i, v := range obstacle.Vertices {
+					obstacle.Vertices[i] = Point{math.Round(v.X), math.Round(v.Y)}
+				}
+				obstacles = append(obstacles, obstacle)
+			}
+		}
+		full := PrepareScene(obstacles, GraphOptions{})
+		reduced := PrepareScene(obstacles, GraphOptions{Reduced: true})
+
+		var vertices []Point
+		for _, obstacle := range obstacles {
+			vertices = append(vertices, obstacle.Vertices...)
+		}
+		box := newBoundingBox(vertices)
+		free := func() Point {
+			for {
+				p := Point{box.min.X - 5 + (box.max.X-box.min.X+10)*r.Float64(), box.min.Y - 5 + (box.max.Y-box.min.Y+10)*r.Float64()}
+				if isPathClear([]Point{p}, obstacles) {
+					return p
+				}
+			}
+		}
+
+		for query := 0; query < 30; query++ {
+			obstacle := obstacles[r.IntN(len(obstacles))]
+			i := r.IntN(len(obstacle.Vertices))
+			a, b := obstacle.Vertices[i], obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
+			onEdge := Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+			var start Point
+			switch query % 3 {
+			case 0:
+				start = free()
+			case 1:
+				start = a
+			default:
+				start = onEdge
+			}
+			target := free()
+			step := fmt.Sprintf("seed %d from %v to %v", seed, start, target)
+
+			want := shortestLength(t, full, start, target)
+			if got := shortestLength(t, reduced, start, target); math.Abs(got-want) > 1e-9*want {
+				t.Errorf("%s: reduced graph gives length %v, full graph %v", step, got, want)
+			}
+			if got := shortestLength(t, reduced, target, start); math.Abs(got-want) > 1e-9*want {
+				t.Errorf("%s: reduced graph gives length %v backwards, full graph %v", step, got, want)
+			}
+
+			if start == onEdge && orientation(a, b, onEdge) == 0 {
+				// Just off the edge on the side away from the obstacle
+				normal := Point{(b.Y - a.Y) * 1e-9, (a.X - b.X) * 1e-9}
+				off := Point{onEdge.X + normal.X, onEdge.Y + normal.Y}
+				if obstacle.Contains(off) {
+					off = Point{onEdge.X - normal.X, onEdge.Y - normal.Y}
+				}
+				if nearby := shortestLength(t, full, off, target); math.Abs(want-nearby) > 1e-6 {
+					t.Errorf("%s: full graph gives length %v, %v from %v just off the edge", step, want, nearby, off)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package sedv2
+
+import "slices"
+
+// rotationTree enumerates all pairs of points ordered by slope in O(n²) time,
+// following Overmars and Welzl, "New methods for computing visibility graphs"
+// (1988). Every point p rotates a ray from pointing straight down to pointing
+// straight up, meeting the points to its right one after another; the tree
+// links each point to the point its ray currently points at, so that the next
+// point can be found in amortized constant time.
+//
+// Besides the points, the tree holds two points at infinity: minusInf straight
+// below every point, the initial parent of all of them, and plusInf straight
+// above, the root. Children are kept in the order in which their rays point at
+// their parent, the leftmost child having the smallest slope.
+type rotationTree struct {
+	points   []Point
+	minusInf int
+	plusInf  int
+
+	parent    []int
+	leftmost  []int
+	rightmost []int
+	left      []int
+	right     []int
+}
+
+const noNode = -1
+
+func newRotationTree(points []Point) *rotationTree {
+	n := len(points)
+	tree := &rotationTree{
+		points:    points,
+		minusInf:  n,
+		plusInf:   n + 1,
+		parent:    make([]int, n+2),
+		leftmost:  make([]int, n+2),
+		rightmost: make([]int, n+2),
+		left:      make([]int, n+2),
+		right:     make([]int, n+2),
+	}
+	for i := range tree.parent {
+		tree.parent[i], tree.leftmost[i], tree.rightmost[i] = noNode, noNode, noNode
+		tree.left[i], tree.right[i] = noNode, noNode
+	}
+
+	tree.insertRightmost(tree.minusInf, tree.plusInf)
+
+	// A ray pointing straight down meets minusInf first from the points
+	// farthest to the right, so they lead the children of minusInf
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(i, j int) int {
+		return comparePoints(points[j], points[i])
+	})
+	for _, i := range order {
+		tree.insertRightmost(i, tree.minusInf)
+	}
+
+	return tree
+}
+
+func (t *rotationTree) insertRightmost(node, parent int) {
+	t.parent[node] = parent
+	t.left[node], t.right[node] = t.rightmost[parent], noNode
+	if t.rightmost[parent] != noNode {
+		t.right[t.rightmost[parent]] = node
+	} else {
+		t.leftmost[parent] = node
+	}
+	t.rightmost[parent] = node
+}
+
+func (t *rotationTree) insertLeftOf(node, sibling int) {
+	parent := t.parent[sibling]
+	t.parent[node] = parent
+	t.left[node], t.right[node] = t.left[sibling], sibling
+	if t.left[sibling] != noNode {
+		t.right[t.left[sibling]] = node
+	} else {
+		t.leftmost[parent] = node
+	}
+	t.left[sibling] = node
+}
+
+func (t *rotationTree) remove(node int) {
+	parent := t.parent[node]
+	if t.left[node] != noNode {
+		t.right[t.left[node]] = t.right[node]
+	} else {
+		t.leftmost[parent] = t.right[node]
+	}
+	if t.right[node] != noNode {
+		t.left[t.right[node]] = t.left[node]
+	} else {
+		t.rightmost[parent] = t.left[node]
+	}
+	t.parent[node], t.left[node], t.right[node] = noNode, noNode, noNode
+}
+
+// isReady reports whether node is a point whose ray can be advanced: a leaf
+// that is the leftmost child of its parent and has not reached plusInf yet.
+func (t *rotationTree) isReady(node int) bool {
+	return node != t.minusInf && t.parent[node] != t.plusInf &&
+		t.leftmost[node] == noNode && t.left[node] == noNode
+}
+
+// meetsBefore reports whether the ray of p, rotating counter-clockwise, meets
+// the point c before it meets z. Of two points in the same direction the
+// nearer one is met first.
+func (t *rotationTree) meetsBefore(p, c, z int) bool {
+	pp, cp := t.points[p], t.points[c]
+	if z == t.plusInf {
+		return cp.X > pp.X || cp.X == pp.X && cp.Y > pp.Y
+	}
+	zp := t.points[z]
+	if o := orientation(pp, cp, zp); o != 0 {
+		return o > 0
+	}
+	return isBetween(pp, zp, cp)
+}
+
+// sweep calls handle for every pair of points p and q with q to the right of
+// p, or straight above it, visiting the pairs of each p in order of slope. By
+// the time handle is called for p and q, the pairs of q with smaller slopes
+// have all been handled and none of the others have.
+func (t *rotationTree) sweep(handle func(p, q int)) {
+	stack := []int{t.leftmost[t.minusInf]}
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if p == noNode || !t.isReady(p) {
+			continue
+		}
+
+		q := t.parent[p]
+		if q != t.minusInf {
+			handle(p, q)
+		}
+
+		r, z := t.parent[q], t.left[q]
+		brother := t.right[p]
+		t.remove(p)
+
+		if z == noNode || !t.meetsBefore(p, z, r) {
+			t.insertLeftOf(p, q)
+		} else {
+			// The next point is where the ray of p becomes tangent to the
+			// chain of rightmost children starting at z
+			for t.rightmost[z] != noNode && t.meetsBefore(p, t.rightmost[z], z) {
+				z = t.rightmost[z]
+			}
+			t.insertRightmost(p, z)
+		}
+
+		if t.isReady(p) {
+			stack = append(stack, p)
+		}
+		if brother != noNode && t.isReady(brother) {
+			stack = append(stack, brother)
+		}
+	}
+}
+
+// rotationTreeVisibility finds the visible vertices of every one of the given
+// obstacle vertices in a single rotation tree sweep. It returns them in the
+// same layout as sweepAll, each visible pair being reported once.
+//
+// Along with the sweep every vertex keeps the obstacle edge its ray meets first
+// once it has turned past its last point, so whether a pair is blocked is
+// decided in constant time. While the ray meets several points in the same
+// direction it also keeps what it meets in exactly that direction, which can
+// differ when the points lie on obstacle corners.
+func (s *Scene) rotationTreeVisibility(vertices []Point) [][]Point {
+	var edges []Segment
+	for _, obstacle := range s.obstacles {
+		edges = append(edges, obstacle.edges()...)
+	}
+
+	seen := make([]*Segment, len(vertices))
+	for i, v := range vertices {
+		seen[i] = firstEdgeBelow(v, edges)
+	}
+
+	last := make([]int, len(vertices))
+	for i := range last {
+		last[i] = noNode
+	}
+	along := make([]*Segment, len(vertices))
+	blockedAlong := make([]bool, len(vertices))
+	turnedAtCorner := make([]bool, len(vertices))
+
+	visible := make([][]Point, len(vertices))
+	newRotationTree(vertices).sweep(func(p, q int) {
+		pp, qp := vertices[p], vertices[q]
+		if pp == qp {
+			return
+		}
+
+		sameDirection := last[p] != noNode && orientation(pp, vertices[last[p]], qp) == 0
+		last[p] = q
+		if !sameDirection {
+			turnedAtCorner[p] = false
+		}
+
+		view, blocked := seen[p], false
+		if sameDirection {
+			view, blocked = along[p], blockedAlong[p]
+		}
+		if blocked || view != nil && s.blocks(*view, pp, qp) {
+			along[p], blockedAlong[p] = view, blocked
+			return
+		}
+
+		if !entersObstacle(pp, qp, s.vertexInfo) && !entersObstacle(qp, pp, s.vertexInfo) && s.keepsEdge(pp, qp) {
+			visible[p] = append(visible[p], qp)
+		}
+
+		// Straight past q the ray of p meets what the ray of q, which has not
+		// turned this far yet, meets, unless it runs into the obstacle of q
+		along[p], blockedAlong[p] = seen[q], passesInto(pp, qp, s.vertexInfo)
+
+		// Once turned past q it meets the edges of q on the side it is turning
+		// to, or otherwise the same, unless it already meets the edges of a
+		// nearer point in this direction
+		if turnedAtCorner[p] {
+			return
+		}
+		info := s.vertexInfo[qp]
+		prevEdge, nextEdge := Segment{info.prev, qp}, Segment{qp, info.next}
+		prevAhead, nextAhead := orientation(pp, qp, info.prev) > 0, orientation(pp, qp, info.next) > 0
+		turnedAtCorner[p] = prevAhead || nextAhead
+		switch {
+		case prevAhead && nextAhead:
+			if orientation(qp, info.next, info.prev) > 0 {
+				seen[p] = &prevEdge
+			} else {
+				seen[p] = &nextEdge
+			}
+		case prevAhead:
+			seen[p] = &prevEdge
+		case nextAhead:
+			seen[p] = &nextEdge
+		default:
+			seen[p] = seen[q]
+		}
+	})
+
+	return visible
+}
+
+// blocks reports whether the obstacle edge keeps p from seeing q, see
+// blocksSegment.
+func (s *Scene) blocks(edge Segment, p, q Point) bool {
+	return blocksSegment(edge, p, q, s.vertexInfo)
+}
+
+// blocksSegment reports whether the obstacle edge keeps p from seeing q:
+// whether it crosses pq, or ends on pq at a vertex where pq runs into the
+// vertex's obstacle. Running along the edge or touching a vertex from outside
+// the obstacle does not block.
+func blocksSegment(edge Segment, p, q Point, vertices map[Point]vertexInfo) bool {
+	d1, d2 := orientation(p, q, edge.start), orientation(p, q, edge.end)
+	d3, d4 := orientation(edge.start, edge.end, p), orientation(edge.start, edge.end, q)
+	if d1*d2 < 0 && d3*d4 < 0 {
+		return true
+	}
+	for _, v := range []Point{edge.start, edge.end} {
+		if v != p && v != q && orientation(p, q, v) == 0 && isBetween(p, q, v) &&
+			(entersObstacle(v, q, vertices) || entersObstacle(v, p, vertices)) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstEdgeBelow returns the edge met first by a ray from p pointing straight
+// down, turned by an infinitesimal angle towards the right, or nil if the ray
+// meets none. Edges incident to p are ignored.
+func firstEdgeBelow(p Point, edges []Segment) *Segment {
+	sweep := NewSegmentTree(p)
+
+	var first *Segment
+	for i, edge := range edges {
+		lo, hi := edge.start, edge.end
+		if lo.X > hi.X {
+			lo, hi = hi, lo
+		}
+		if lo == p || hi == p || lo.X > p.X || hi.X <= p.X || orientation(lo, hi, p) <= 0 {
+			continue
+		}
+		if first == nil {
+			first = &edges[i]
+		} else if order, ok := sweep.compareByOrientation(edge, *first); ok && order < 0 {
+			first = &edges[i]
+		}
+	}
+
+	return first
+}
+
+// entersObstacle reports whether the segment from the obstacle vertex v
+// towards w starts out through the interior of v's obstacle.
+func entersObstacle(v, w Point, vertices map[Point]vertexInfo) bool {
+	info, ok := vertices[v]
+	if !ok {
+		return false
+	}
+	return info.isInterior(orientation(info.prev, v, w), orientation(v, info.next, w))
+}
+
+// passesInto reports whether the ray from p through the obstacle vertex v runs
+// into the interior of v's obstacle past v.
+func passesInto(p, v Point, vertices map[Point]vertexInfo) bool {
+	info, ok := vertices[v]
+	if !ok {
+		return false
+	}
+	return info.isInterior(-orientation(info.prev, v, p), -orientation(v, info.next, p))
+}
+
+// isInterior reports whether a direction leaving the vertex points into the
+// obstacle's interior, given the sides of the incoming and the outgoing edge
+// it lies on.
+func (info vertexInfo) isInterior(prevSide, nextSide int) bool {
+	afterPrev := prevSide*info.winding > 0
+	beforeNext := nextSide*info.winding > 0
+	if info.convex {
+		return afterPrev && beforeNext
+	}
+	return afterPrev || beforeNext
+}
@@ -0,0 +1,72 @@
+package sedv2
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+// randomScene returns one of several kinds of random obstacles inside a
+// boundary, and whether they overlap and have to be merged.
+func randomScene(r *rand.Rand, kind int) ([]Obstacle, bool) {
+	var obstacles []Obstacle
+	overlapping := false
+	switch kind {
+	case 0:
+		for _, cell := range r.Perm(9)[:5] {
+			obstacles = append(obstacles, randomCellObstacle(r, cell%3, cell/3))
+		}
+	case 1:
+		obstacles = randomBoxes(r, 8)
+	default:
+		for i := 0; i < 5; i++ {
+			obstacles = append(obstacles, randomStarObstacle(r, Point{40 + 120*r.Float64(), 40 + 120*r.Float64()}, 10, 35))
+		}
+		obstacles = append(obstacles, Obstacle{
+			Vertices: []Point{{200, 200}, {260, 200}, {260, 260}, {200, 260}},
+			Holes:    [][]Point{{{210, 210}, {210, 250}, {250, 250}, {250, 210}}},
+		})
+		overlapping = true
+	}
+	boundary := Obstacle{Vertices: []Point{{-20, -20}, {320, -20}, {320, 320}, {-20, 320}}, Bounding: true}
+	return append(obstacles, boundary), overlapping
+}
+
+func TestBuildersProduceTheSameGraph(t *testing.T) {
+	for seed := uint64(0); seed < 30; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		obstacles, overlapping := randomScene(r, int(seed%3))
+		for _, reduced := range []bool{false, true} {
+			options := GraphOptions{Reduced: reduced, MergeOverlapping: overlapping}
+			lee := PrepareScene(obstacles, options)
+			options.Builder = RotationTreeBuilder
+			rotationTree := PrepareScene(obstacles, options)
+			checkSameEdges(t, fmt.Sprintf("seed %d reduced %v", seed, reduced), lee, rotationTree)
+		}
+	}
+}
+
+func BenchmarkBuilders(b *testing.B) {
+	for _, count := range []int{10, 20, 40} {
+		r := rand.New(rand.NewPCG(uint64(count), 0))
+		side := 1
+		for side*side < 2*count {
+			side++
+		}
+		var obstacles []Obstacle
+		for _, cell := range r.Perm(side * side)[:count] {
+			obstacles = append(obstacles, randomCellObstacle(r, cell%side, cell/side))
+		}
+		for _, builder := range []GraphBuilder{LeeBuilder, RotationTreeBuilder} {
+			name := "Lee"
+			if builder == RotationTreeBuilder {
+				name = "RotationTree"
+			}
+			b.Run(fmt.Sprintf("%s/%d", name, count), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					PrepareScene(obstacles, GraphOptions{Builder: builder, Workers: 1})
+				}
+			})
+		}
+	}
+}
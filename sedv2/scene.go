@@ -0,0 +1,296 @@
+package sedv2
+
+import (
+	"maps"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// GraphOptions select how a visibility graph is built. The zero value builds
+// the full visibility graph.
+type GraphOptions struct {
+	// Reduced keeps only the edges that can be part of a shortest path: edges
+	// tangent to the obstacles at both of their endpoints, which must be convex
+	// obstacle vertices, S or T. S and T count as tangent in every direction,
+	// even when they lie on obstacle edges or vertices.
+	Reduced bool
+	// Workers bounds the number of goroutines sweeping around obstacle
+	// vertices in parallel. Zero means runtime.GOMAXPROCS.
+	Workers int
+	// Builder selects the algorithm finding the visible pairs of obstacle
+	// vertices. S and T are always connected by sweeping around them.
+	Builder GraphBuilder
+	// MergeOverlapping replaces overlapping obstacles by their union before
+	// the graph is built, so that no path runs through the overlap and no
+	// vertex is left inside another obstacle. Without it the sweeps assume
+	// that obstacles neither overlap nor touch, and paths may run through
+	// the overlap of two obstacles or between two that touch. Obstacles
+	// meeting the boundary are merged into it either way. Obstacles that fail
+	// to merge, see UnionAll, are kept as they are.
+	MergeOverlapping bool
+}
+
+// GraphBuilder selects how the visibility graph between obstacle vertices is
+// computed. The builders produce the same graph: a segment running along
+// obstacle edges or touching vertices from outside is kept, one crossing an
+// edge or passing a vertex into its obstacle is not.
+type GraphBuilder int
+
+const (
+	// LeeBuilder sweeps a ray around every vertex separately, in O(n² log n)
+	// time overall. The sweeps run in parallel.
+	LeeBuilder GraphBuilder = iota
+	// RotationTreeBuilder visits all pairs of vertices in a single pass
+	// ordered by slope, in O(n²) time.
+	RotationTreeBuilder
+)
+
+// Scene holds the visibility graph between the vertices of a fixed set of
+// obstacles, so that paths between many start and target points can be found
+// without recomputing it: a query only sweeps around its own S and T.
+type Scene struct {
+	obstacles  []Obstacle
+	options    GraphOptions
+	vertexInfo map[Point]vertexInfo
+	edges      map[Point][]Point
+	// owned holds the vertices whose adjacency lists the scene has copied
+	// since it was cloned, the others being shared with the scene it was
+	// cloned from. It is nil for a scene owning all of them.
+	owned map[Point]bool
+}
+
+func PrepareScene(S []Obstacle, options GraphOptions) *Scene {
+	if options.MergeOverlapping {
+		S, _ = UnionAll(S...)
+	}
+	scene := &Scene{
+		obstacles:  S,
+		options:    options,
+		vertexInfo: makeVertexInfoMap(S),
+		edges:      make(map[Point][]Point),
+	}
+
+	var vertices []Point
+	for _, obstacle := range S {
+		vertices = append(vertices, obstacle.vertices()...)
+	}
+
+	// Merge in vertex order so the adjacency lists do not depend on how the
+	// sweeps were scheduled
+	added := make(map[[2]Point]bool)
+	addEdge := func(v, w Point) {
+		if added[[2]Point{v, w}] {
+			return
+		}
+		added[[2]Point{v, w}], added[[2]Point{w, v}] = true, true
+		scene.edges[v] = append(scene.edges[v], w)
+		scene.edges[w] = append(scene.edges[w], v)
+	}
+
+	var visible [][]Point
+	switch options.Builder {
+	case RotationTreeBuilder:
+		visible = scene.rotationTreeVisibility(vertices)
+	default:
+		visible = scene.sweepAll(vertices)
+	}
+
+	for i, W := range visible {
+		for _, w := range W {
+			addEdge(vertices[i], w)
+		}
+	}
+
+	for _, obstacle := range S {
+		for _, edge := range obstacle.edges() {
+			if scene.keepsEdge(edge.start, edge.end) {
+				addEdge(edge.start, edge.end)
+			}
+		}
+	}
+
+	return scene
+}
+
+// sweepAll returns the visible vertices of each of the given vertices,
+// distributing the sweeps over the configured number of workers.
+func (s *Scene) sweepAll(vertices []Point) [][]Point {
+	visible := make([][]Point, len(vertices))
+	s.parallelFor(len(vertices), func(index int) {
+		visible[index] = s.visibleVertices(vertices[index])
+	})
+	return visible
+}
+
+// parallelFor calls f for every index below n, running the calls on the
+// configured number of workers.
+func (s *Scene) parallelFor(n int, f func(index int)) {
+	workers := s.options.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, n)
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indices {
+				f(index)
+			}
+		}()
+	}
+
+	for index := 0; index < n; index++ {
+		indices <- index
+	}
+	close(indices)
+	wg.Wait()
+}
+
+func (s *Scene) Obstacles() []Obstacle {
+	return s.obstacles
+}
+
+// VisibilityGraph returns the visibility graph of the scene with start and
+// target added. The obstacle edges are shared with the scene and with every
+// other graph it returned; only the edges of start and target are the graph's
+// own.
+func (s *Scene) VisibilityGraph(start, target Point) VisibilityGraph {
+	visibilityGraph := NewVisibilityGraph(start, target)
+	visibilityGraph.base = s.edges
+
+	for _, p := range []Point{start, target} {
+		s.connect(&visibilityGraph, p)
+	}
+
+	if s.seesDirectly(start, target) {
+		visibilityGraph.AddEdges(start, []Point{target})
+		visibilityGraph.AddEdges(target, []Point{start})
+	}
+
+	return visibilityGraph
+}
+
+// connect adds the edges between p and the obstacle vertices it sees to the
+// graph. A point on an obstacle edge is swept around as a vertex splitting the
+// edge, and as paths start or end at p rather than bend there, the edges of
+// the reduced graph need only be tangent at their obstacle vertex.
+func (s *Scene) connect(visibilityGraph *VisibilityGraph, p Point) {
+	obstacles, vertices := s.splitAt(p)
+	W := slices.DeleteFunc(VisibleVertices(p, obstacles), func(w Point) bool {
+		return entersObstacle(p, w, vertices) || entersObstacle(w, p, vertices) ||
+			s.options.Reduced && !isTangentAt(p, w, vertices)
+	})
+	visibilityGraph.AddEdges(p, W)
+	for _, w := range W {
+		visibilityGraph.AddEdges(w, []Point{p})
+	}
+}
+
+func (s *Scene) visibleVertices(p Point) []Point {
+	W := VisibleVertices(p, s.obstacles)
+	// The sweep only tests the obstacle of w for the way the segment leaves
+	// w, and ignores the edges of p, so segments leaving p into its own
+	// obstacle are dropped here by the same exact test.
+	W = slices.DeleteFunc(W, func(w Point) bool {
+		return entersObstacle(p, w, s.vertexInfo) || entersObstacle(w, p, s.vertexInfo) || !s.keepsEdge(p, w)
+	})
+	return W
+}
+
+// seesDirectly reports whether the segment between the points p and q, which
+// may lie on obstacle edges, avoids the obstacles by the same rules as the
+// sweeps: running along an edge or touching a vertex from outside does not
+// block it.
+func (s *Scene) seesDirectly(p, q Point) bool {
+	obstacles, vertices := s.splitAt(p, q)
+	for _, obstacle := range obstacles {
+		for _, edge := range obstacle.edges() {
+			if blocksSegment(edge, p, q, vertices) {
+				return false
+			}
+		}
+	}
+	if entersObstacle(p, q, vertices) || entersObstacle(q, p, vertices) {
+		return false
+	}
+
+	// Without crossing an edge the segment lies inside an obstacle only if
+	// its endpoints off the obstacle boundaries do
+	for _, endpoint := range []Point{p, q} {
+		if _, ok := vertices[endpoint]; ok {
+			continue
+		}
+		for _, obstacle := range obstacles {
+			if obstacle.Contains(endpoint) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitAt returns the scene's obstacles and vertex information with each of
+// the points that lies inside an obstacle edge inserted into the edge as a
+// vertex. The scene's own are returned when none of them does.
+func (s *Scene) splitAt(points ...Point) ([]Obstacle, map[Point]vertexInfo) {
+	obstacles, vertices := s.obstacles, s.vertexInfo
+	copied := false
+	for _, p := range points {
+		if _, ok := vertices[p]; ok {
+			continue
+		}
+		i, r, k, ok := findEdgeThrough(obstacles, p)
+		if !ok {
+			continue
+		}
+		if !copied {
+			obstacles, vertices = slices.Clone(obstacles), maps.Clone(vertices)
+			copied = true
+		}
+
+		obstacle := obstacles[i].clone()
+		ring := obstacle.rings()[r]
+		a, b := ring[k], ring[(k+1)%len(ring)]
+		ring = slices.Insert(ring, k+1, p)
+		if r == 0 {
+			obstacle.Vertices = ring
+		} else {
+			obstacle.Holes[r-1] = ring
+		}
+		obstacles[i] = obstacle
+
+		info := vertices[a]
+		info.next = p
+		vertices[a] = info
+		info = vertices[b]
+		info.prev = p
+		vertices[b] = info
+		vertices[p] = vertexInfo{prev: a, next: b, winding: obstacle.winding(r), convex: true}
+	}
+	return obstacles, vertices
+}
+
+// findEdgeThrough returns the obstacle, ring and index within the ring of the
+// start of an edge having p in its interior.
+func findEdgeThrough(obstacles []Obstacle, p Point) (int, int, int, bool) {
+	for i, obstacle := range obstacles {
+		for r, ring := range obstacle.rings() {
+			for k, a := range ring {
+				b := ring[(k+1)%len(ring)]
+				if p != a && p != b && orientation(a, b, p) == 0 && isBetween(a, b, p) {
+					return i, r, k, true
+				}
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+func (s *Scene) keepsEdge(u, v Point) bool {
+	return !s.options.Reduced || isTangentEdge(u, v, s.vertexInfo)
+}
@@ -0,0 +1,31 @@
+package sedv2
+
+import (
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+// TestPrepareSceneWorkers checks that the sweeps give the same graph, down to
+// the order of every vertex's neighbours, however many workers share them.
+// Run it with -race to check the workers for data races as well.
+func TestPrepareSceneWorkers(t *testing.T) {
+	for seed := uint64(0); seed < 6; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		obstacles, overlapping := randomScene(r, int(seed%3))
+		for _, reduced := range []bool{false, true} {
+			options := GraphOptions{Reduced: reduced, MergeOverlapping: overlapping, Workers: 1}
+			want := PrepareScene(obstacles, options)
+			for _, workers := range []int{2, 3, 8, 0} {
+				options.Workers = workers
+				got := PrepareScene(obstacles, options)
+				if !maps.EqualFunc(got.edges, want.edges, slices.Equal) {
+					checkSameEdges(t, fmt.Sprintf("seed %d reduced %v with %d workers", seed, reduced, workers), got, want)
+					t.Errorf("seed %d reduced %v with %d workers: the neighbours are ordered differently than with one", seed, reduced, workers)
+				}
+			}
+		}
+	}
+}
@@ -1,188 +1,326 @@
 package sedv2
 
 import (
-	"github.com/emirpasic/gods/sets/treeset"
 	"math"
-	"slices"
+	"math/rand/v2"
 )
 
 type Segment struct {
 	start, end Point
 }
 
+// normalized returns the segment with its endpoints in comparePoints order, so
+// both directions of an edge map to the same key.
+func (s Segment) normalized() Segment {
+	if comparePoints(s.end, s.start) < 0 {
+		return Segment{s.end, s.start}
+	}
+	return s
+}
+
 type SegmentIntersection struct {
 	segment Segment
 }
 
+// SegmentIntersectionTree is the status structure of the rotational sweep: the
+// obstacle edges crossed by the sweep ray, ordered by the distance from point at
+// which the ray meets them. It is a treap, so insertions, deletions and the
+// leftmost lookup take expected O(log n) time. Nodes are reached through their
+// segment rather than by searching with compare, so an edge can always be
+// removed even after the ray has rotated past the order it was inserted in.
 type SegmentIntersectionTree struct {
-	set        *treeset.Set
+	root       *sweepNode
+	nodes      map[Segment]*sweepNode
 	point      Point
 	currRaydir Point
+	rayTarget  Point
 }
 
-func (s SegmentIntersection) pointRayDistance(point Point, raydir Point) (float32, bool) {
+type sweepNode struct {
+	value               SegmentIntersection
+	priority            uint64
+	left, right, parent *sweepNode
+}
+
+func (s SegmentIntersection) pointRayDistance(point Point, raydir Point) (float64, bool) {
 	intersection, didIntersect := point.GetIntersectionWithRay(raydir, s.segment.start, s.segment.end)
 	if !didIntersect {
-		return math.MaxFloat32, false
+		return math.Inf(1), false
 	}
 
 	return point.Distance(intersection), true
 }
 
 func NewSegmentTree(point Point) *SegmentIntersectionTree {
-	tree := &SegmentIntersectionTree{point: point}
-
-	segmentIntersectionComparator := func(a, b interface{}) int {
-		aSegment := a.(SegmentIntersection)
-		bSegment := b.(SegmentIntersection)
-
-		if aSegment.segment.start == bSegment.segment.start && aSegment.segment.end == bSegment.segment.end ||
-			aSegment.segment.end == bSegment.segment.start && aSegment.segment.start == bSegment.segment.end {
-			return 0
-		}
+	return &SegmentIntersectionTree{
+		point: point,
+		nodes: make(map[Segment]*sweepNode),
+	}
+}
 
-		aDistance, _ := aSegment.pointRayDistance(tree.point, tree.currRaydir)
-		bDistance, _ := bSegment.pointRayDistance(tree.point, tree.currRaydir)
+// compare orders segments by the distance from the tree's point at which the
+// current ray meets them, segments missed by the ray coming last. Segments that
+// cross the ray without crossing each other are ordered with exact orientation
+// tests; the ray distance is only consulted for degenerate input.
+func (s *SegmentIntersectionTree) compare(a, b SegmentIntersection) int {
+	if a.segment.start == b.segment.start && a.segment.end == b.segment.end ||
+		a.segment.end == b.segment.start && a.segment.start == b.segment.end {
+		return 0
+	}
 
-		if aDistance < bDistance {
+	aHits, bHits := s.hitsRay(a.segment), s.hitsRay(b.segment)
+	if aHits != bHits {
+		if aHits {
 			return -1
 		}
-		if aDistance > bDistance {
-			return 1
+		return 1
+	}
+
+	if aHits {
+		if order, ok := s.compareByOrientation(a.segment, b.segment); ok {
+			return order
 		}
+	}
 
-		pointsA := [2]Point{aSegment.segment.start, aSegment.segment.end}
-		pointsB := [2]Point{bSegment.segment.start, bSegment.segment.end}
+	aDistance, _ := a.pointRayDistance(s.point, s.currRaydir)
+	bDistance, _ := b.pointRayDistance(s.point, s.currRaydir)
 
-		aSamePointIndex, bSamePointIndex := -1, -1
-		for i := 0; i < 2; i++ {
-			for j := 0; j < 2; j++ {
-				if pointsA[i] == pointsB[j] {
-					aSamePointIndex, bSamePointIndex = i, j
-				}
-			}
-		}
-		aNonSamePointIndex, bNonSamePointIndex := (aSamePointIndex+1)%2, (bSamePointIndex+1)%2
-		angleA := tree.point.Angle(pointsA[aNonSamePointIndex])
-		angleB := tree.point.Angle(pointsB[bNonSamePointIndex])
+	if aDistance < bDistance {
+		return -1
+	}
+	if aDistance > bDistance {
+		return 1
+	}
 
-		if angleA < angleB {
-			return -1
-		}
-		if angleA > angleB {
-			return 1
-		}
+	return compareSegments(a.segment, b.segment)
+}
 
-		return 0
+// compareSegments is an arbitrary but deterministic total order on undirected
+// segments, used so that distinct segments never compare equal.
+func compareSegments(a, b Segment) int {
+	aLow, aHigh := a.start, a.end
+	if comparePoints(aHigh, aLow) < 0 {
+		aLow, aHigh = aHigh, aLow
+	}
+	bLow, bHigh := b.start, b.end
+	if comparePoints(bHigh, bLow) < 0 {
+		bLow, bHigh = bHigh, bLow
 	}
+	if order := comparePoints(aLow, bLow); order != 0 {
+		return order
+	}
+	return comparePoints(aHigh, bHigh)
+}
 
-	tree.set = treeset.NewWith(segmentIntersectionComparator)
+func (s *SegmentIntersectionTree) hitsRay(segment Segment) bool {
+	return rayHitsSegment(s.point, s.rayTarget, segment.start, segment.end)
+}
 
-	return tree
+func (s *SegmentIntersectionTree) compareByOrientation(a, b Segment) (int, bool) {
+	if s.isBehind(b, a) || s.isInFront(a, b) {
+		return -1, true
+	}
+	if s.isBehind(a, b) || s.isInFront(b, a) {
+		return 1, true
+	}
+	return 0, false
 }
 
-func (s *SegmentIntersectionTree) AddSegmentIntersection(segment Segment) {
-	s.set.Add(SegmentIntersection{segment})
+// isBehind reports whether segment b lies entirely on the far side of the line
+// through segment a, as seen from the tree's point.
+func (s *SegmentIntersectionTree) isBehind(b, a Segment) bool {
+	return s.isOnSide(b, a, -1)
 }
 
-func (s *SegmentIntersectionTree) RemoveSegmentIntersection(si SegmentIntersection) {
-	s.set.Remove(si)
+// isInFront reports whether segment a lies entirely on the tree point's side of
+// the line through segment b.
+func (s *SegmentIntersectionTree) isInFront(a, b Segment) bool {
+	return s.isOnSide(a, b, 1)
 }
 
-func (s *SegmentIntersectionTree) GetLeftmostSegmentIntersection() (SegmentIntersection, bool) {
-	values := s.set.Values()
-	if len(values) == 0 {
-		return SegmentIntersection{}, false
+func (s *SegmentIntersectionTree) isOnSide(of, line Segment, side int) bool {
+	if line.start == line.end || of.start == of.end {
+		return false
+	}
+	pointSide := orientation(line.start, line.end, s.point)
+	if pointSide == 0 {
+		return false
 	}
-	segmentIntersections := make([]SegmentIntersection, len(values))
-	for i, value := range values {
-		segmentIntersections[i] = value.(SegmentIntersection)
+	startSide := orientation(line.start, line.end, of.start)
+	endSide := orientation(line.start, line.end, of.end)
+	if startSide == 0 && endSide == 0 {
+		return false
 	}
+	want := side * pointSide
+	return (startSide == 0 || startSide == want) && (endSide == 0 || endSide == want)
+}
+
+// SetRay points the sweep ray from the tree's point through target.
+func (s *SegmentIntersectionTree) SetRay(target Point) {
+	s.rayTarget = target
+	s.currRaydir = Point{target.X - s.point.X, target.Y - s.point.Y}
+}
 
-	segmentIntersectionComparator := func(a, b SegmentIntersection) int {
-		aSegment := a
-		bSegment := b
+// AddSegmentIntersection inserts segment at its position along the current
+// ray. Adding a segment that is already in the tree, in either direction, does
+// nothing.
+func (s *SegmentIntersectionTree) AddSegmentIntersection(segment Segment) {
+	key := segment.normalized()
+	if _, exists := s.nodes[key]; exists {
+		return
+	}
 
-		if aSegment.segment.start == bSegment.segment.start && aSegment.segment.end == bSegment.segment.end ||
-			aSegment.segment.end == bSegment.segment.start && aSegment.segment.start == bSegment.segment.end {
-			return 0
+	node := &sweepNode{value: SegmentIntersection{segment}, priority: rand.Uint64()}
+	s.nodes[key] = node
+
+	var parent *sweepNode
+	goLeft := false
+	for current := s.root; current != nil; {
+		parent = current
+		goLeft = s.compare(node.value, current.value) < 0
+		if goLeft {
+			current = current.left
+		} else {
+			current = current.right
 		}
+	}
 
-		aDistance, _ := aSegment.pointRayDistance(s.point, s.currRaydir)
-		bDistance, _ := bSegment.pointRayDistance(s.point, s.currRaydir)
+	node.parent = parent
+	switch {
+	case parent == nil:
+		s.root = node
+	case goLeft:
+		parent.left = node
+	default:
+		parent.right = node
+	}
 
-		if aDistance < bDistance {
-			return -1
-		}
-		if aDistance > bDistance {
-			return 1
-		}
+	for node.parent != nil && node.priority > node.parent.priority {
+		s.rotateUp(node)
+	}
+}
 
-		pointsA := [2]Point{aSegment.segment.start, aSegment.segment.end}
-		pointsB := [2]Point{bSegment.segment.start, bSegment.segment.end}
+// RemoveSegmentIntersection removes the segment of si, in either direction, if
+// it is in the tree.
+func (s *SegmentIntersectionTree) RemoveSegmentIntersection(si SegmentIntersection) {
+	key := si.segment.normalized()
+	node, exists := s.nodes[key]
+	if !exists {
+		return
+	}
+	delete(s.nodes, key)
 
-		aSamePointIndex, bSamePointIndex := -1, -1
-		for i := 0; i < 2; i++ {
-			for j := 0; j < 2; j++ {
-				if pointsA[i] == pointsB[j] {
-					aSamePointIndex, bSamePointIndex = i, j
-				}
-			}
+	for node.left != nil || node.right != nil {
+		child := node.left
+		if child == nil || node.right != nil && node.right.priority > child.priority {
+			child = node.right
 		}
-		aNonSamePointIndex, bNonSamePointIndex := (aSamePointIndex+1)%2, (bSamePointIndex+1)%2
-		angleA := s.point.Angle(pointsA[aNonSamePointIndex])
-		angleB := s.point.Angle(pointsB[bNonSamePointIndex])
+		s.rotateUp(child)
+	}
+	s.replaceChild(node.parent, node, nil)
+}
 
-		if angleA < angleB {
-			return -1
+// rotateUp moves node one level up, making its parent its child.
+func (s *SegmentIntersectionTree) rotateUp(node *sweepNode) {
+	parent := node.parent
+	grandparent := parent.parent
+
+	if parent.left == node {
+		parent.left = node.right
+		if node.right != nil {
+			node.right.parent = parent
 		}
-		if angleA > angleB {
-			return 1
+		node.right = parent
+	} else {
+		parent.right = node.left
+		if node.left != nil {
+			node.left.parent = parent
 		}
-
-		return 0
+		node.left = parent
 	}
 
-	slices.SortFunc(segmentIntersections, segmentIntersectionComparator)
-
-	return segmentIntersections[0], true
+	parent.parent = node
+	node.parent = grandparent
+	s.replaceChild(grandparent, parent, node)
 }
 
-func makeSegmentIntersectionFromPoint(a Point) SegmentIntersection {
-	return SegmentIntersection{Segment{a, a}}
+func (s *SegmentIntersectionTree) replaceChild(parent, old, new *sweepNode) {
+	switch {
+	case parent == nil:
+		s.root = new
+	case parent.left == old:
+		parent.left = new
+	default:
+		parent.right = new
+	}
 }
 
-func (s *SegmentIntersectionTree) FindPossibleIntersections(a, b Point) []Segment {
-	aSI, bSI := makeSegmentIntersectionFromPoint(a), makeSegmentIntersectionFromPoint(b)
-
-	s.set.Add(aSI)
-
-	aIndex, _ := s.set.Find(func(index int, value interface{}) bool {
-		return value == aSI
-	})
-
-	s.RemoveSegmentIntersection(aSI)
-
-	s.set.Add(bSI)
-
-	bIndex, _ := s.set.Find(func(index int, value interface{}) bool {
-		return value == bSI
-	})
+func leftmost(node *sweepNode) *sweepNode {
+	for node.left != nil {
+		node = node.left
+	}
+	return node
+}
 
-	s.RemoveSegmentIntersection(bSI)
+func successor(node *sweepNode) *sweepNode {
+	if node.right != nil {
+		return leftmost(node.right)
+	}
+	for node.parent != nil && node.parent.right == node {
+		node = node.parent
+	}
+	return node.parent
+}
 
-	var intersections []Segment
+func (s *SegmentIntersectionTree) GetLeftmostSegmentIntersection() (SegmentIntersection, bool) {
+	if s.root == nil {
+		return SegmentIntersection{}, false
+	}
+	return leftmost(s.root).value, true
+}
 
-	if aIndex != -1 && bIndex != -1 {
-		if aIndex > bIndex {
-			aIndex, bIndex = bIndex, aIndex
+// FindPossibleIntersections returns the segments that cross the segment ab,
+// where a and b lie on the current ray. Only the segments the ray meets strictly
+// between a and b are visited, so the query takes O(log n + k) time for k
+// segments in that range.
+func (s *SegmentIntersectionTree) FindPossibleIntersections(a, b Point) []Segment {
+	var first *sweepNode
+	for node := s.root; node != nil; {
+		if s.reachedBy(node.value.segment, a) {
+			node = node.right
+		} else {
+			first = node
+			node = node.left
 		}
+	}
 
-		for i := aIndex; i <= bIndex; i++ {
-			item := s.set.Values()[i].(SegmentIntersection)
-			intersections = append(intersections, item.segment)
+	var intersections []Segment
+	for node := first; node != nil && s.reachedBefore(node.value.segment, b); node = successor(node) {
+		segment := node.value.segment
+		if doSegmentsIntersect(a, b, segment.start, segment.end) {
+			intersections = append(intersections, segment)
 		}
 	}
 
 	return intersections
 }
+
+// reachedBy reports whether the ray meets segment no farther from the tree's
+// point than q, which must lie on the ray.
+func (s *SegmentIntersectionTree) reachedBy(segment Segment, q Point) bool {
+	if !s.hitsRay(segment) {
+		return false
+	}
+	pointSide := orientation(segment.start, segment.end, s.point)
+	return pointSide == 0 || orientation(segment.start, segment.end, q) != pointSide
+}
+
+// reachedBefore reports whether the ray meets segment strictly closer to the
+// tree's point than q, which must lie on the ray.
+func (s *SegmentIntersectionTree) reachedBefore(segment Segment, q Point) bool {
+	if !s.hitsRay(segment) {
+		return false
+	}
+	pointSide := orientation(segment.start, segment.end, s.point)
+	return pointSide == 0 || orientation(segment.start, segment.end, q) == -pointSide
+}
@@ -0,0 +1,146 @@
+package sedv2
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// checkTreap checks the treap's links, its heap order on priorities and its
+// index of nodes, and returns its segments in order.
+func checkTreap(t *testing.T, step string, tree *SegmentIntersectionTree) []Segment {
+	t.Helper()
+	var segments []Segment
+	var walk func(node, parent *sweepNode)
+	walk = func(node, parent *sweepNode) {
+		if node == nil {
+			return
+		}
+		if node.parent != parent {
+			t.Fatalf("%s: %v has parent %v, want %v", step, node.value.segment, node.parent, parent)
+		}
+		if parent != nil && node.priority > parent.priority {
+			t.Fatalf("%s: %v has a higher priority than its parent %v", step, node.value.segment, parent.value.segment)
+		}
+		walk(node.left, node)
+		segments = append(segments, node.value.segment)
+		if tree.nodes[node.value.segment.normalized()] != node {
+			t.Fatalf("%s: %v is missing from the index of nodes", step, node.value.segment)
+		}
+		walk(node.right, node)
+	}
+	walk(tree.root, nil)
+	if len(tree.nodes) != len(segments) {
+		t.Fatalf("%s: %d indexed nodes, %d in the tree", step, len(tree.nodes), len(segments))
+	}
+	return segments
+}
+
+// TestSegmentTreeMatchesSortedSlice inserts and removes random segments in a
+// sweep status, checking it after every step against a slice kept sorted by
+// the same comparison. Every segment lies in its own strip across the ray, so
+// the segments the ray meets must be ordered by their strips. In some strips
+// two segments meet at a vertex on the ray, at the same distance, and their
+// order comes from the orientation tests alone.
+func TestSegmentTreeMatchesSortedSlice(t *testing.T) {
+	for seed := uint64(0); seed < 20; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		tree := NewSegmentTree(Point{0, 0})
+		tree.SetRay(Point{1, 0})
+
+		// The segments of strip k lie between x = 10k - 4 and x = 10k + 4
+		var segments []Segment
+		strip := make(map[Segment]int)
+		offset := func() float64 { return float64(r.IntN(9) - 4) }
+		height := func() float64 { return float64(1+r.IntN(9)) * float64(2*r.IntN(2)-1) }
+		for k := 1; k <= 40; k++ {
+			x := float64(10 * k)
+			switch r.IntN(3) {
+			case 0:
+				// Crossing the ray
+				segments = append(segments, Segment{Point{x + offset(), -float64(1 + r.IntN(9))}, Point{x + offset(), float64(1 + r.IntN(9))}})
+			case 1:
+				// Two segments meeting on the ray
+				vertex := Point{x, 0}
+				a := Point{x + offset(), height()}
+				b := Point{x + offset(), height()}
+				for orientation(vertex, a, b) == 0 {
+					b = Point{x + offset(), height()}
+				}
+				segments = append(segments, Segment{vertex, a}, Segment{b, vertex})
+			default:
+				// Missing the ray
+				y := height()
+				segments = append(segments, Segment{Point{x + offset(), y}, Point{x + offset(), y + height()}})
+			}
+			for _, segment := range segments[len(strip):] {
+				strip[segment.normalized()] = k
+			}
+		}
+
+		var want []Segment
+		for step := 0; step < 400; step++ {
+			segment := segments[r.IntN(len(segments))]
+			if r.IntN(2) == 0 {
+				segment = Segment{segment.end, segment.start}
+			}
+			i := slices.IndexFunc(want, func(s Segment) bool { return s.normalized() == segment.normalized() })
+			name := fmt.Sprintf("seed %d step %d", seed, step)
+			if r.IntN(3) != 0 {
+				name += fmt.Sprintf(" adding %v", segment)
+				tree.AddSegmentIntersection(segment)
+				if i < 0 {
+					at := sort.Search(len(want), func(j int) bool {
+						return tree.compare(SegmentIntersection{segment}, SegmentIntersection{want[j]}) < 0
+					})
+					want = slices.Insert(want, at, segment)
+				}
+			} else {
+				name += fmt.Sprintf(" removing %v", segment)
+				tree.RemoveSegmentIntersection(SegmentIntersection{segment})
+				if i >= 0 {
+					want = slices.Delete(want, i, i+1)
+				}
+			}
+
+			got := checkTreap(t, name, tree)
+			if !slices.EqualFunc(got, want, func(a, b Segment) bool { return a.normalized() == b.normalized() }) {
+				t.Fatalf("%s: got %v, want %v", name, got, want)
+			}
+			leftmost, ok := tree.GetLeftmostSegmentIntersection()
+			if ok != (len(want) > 0) || ok && leftmost.segment.normalized() != want[0].normalized() {
+				t.Fatalf("%s: got leftmost %v, %v, want %v", name, leftmost.segment, ok, want)
+			}
+
+			for i := 0; i+1 < len(want); i++ {
+				a, b := want[i], want[i+1]
+				aHits, bHits := tree.hitsRay(a), tree.hitsRay(b)
+				switch {
+				case aHits && bHits && strip[a.normalized()] == strip[b.normalized()]:
+					if order, ok := tree.compareByOrientation(a, b); !ok || order >= 0 {
+						t.Fatalf("%s: %v and %v meeting on the ray are ordered %d, %v by orientation", name, a, b, order, ok)
+					}
+				case aHits && bHits && strip[a.normalized()] > strip[b.normalized()], !aHits && bHits:
+					t.Fatalf("%s: %v comes before %v", name, a, b)
+				}
+			}
+
+			from, to := Point{float64(r.IntN(420)), 0}, Point{float64(r.IntN(420)), 0}
+			if from.X > to.X {
+				from, to = to, from
+			}
+			// Only the segments met strictly between the two points count
+			var crossing []Segment
+			for _, segment := range want {
+				if doSegmentsIntersect(from, to, segment.start, segment.end) && !from.OnSegment(segment.start, segment.end) && !to.OnSegment(segment.start, segment.end) {
+					crossing = append(crossing, segment)
+				}
+			}
+			if got := tree.FindPossibleIntersections(from, to); !slices.Equal(got, crossing) {
+				t.Fatalf("%s: got %v crossing %v to %v, want %v", name, got, from, to, crossing)
+			}
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package sedv2
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ShortestPathMap answers shortest path queries from a fixed start point S to
+// arbitrary points. It partitions the free space by the last vertex on the
+// shortest path from S: a point q belongs to the region of the vertex r, or of
+// S itself, that sees q and minimizes the distance from S to r plus |rq|.
+//
+// Building the map costs one Dijkstra search from S, one rotational sweep
+// around every vertex reached and the point location grid over the regions.
+// A query then only locates q among the regions, without building a
+// visibility graph, see Locate.
+type ShortestPathMap struct {
+	S            Point
+	scene        *Scene
+	predecessors map[Point]Point
+	// roots are the vertices reached from S, S included, ordered by their
+	// distance from S
+	roots []pathMapRoot
+	grid  *locationGrid
+}
+
+type pathMapRoot struct {
+	vertex   Point
+	distance float64
+	region   visibleRegion
+}
+
+// ShortestPathMap builds the shortest path map of the scene for the given start
+// point.
+func (s *Scene) ShortestPathMap(start Point) *ShortestPathMap {
+	visibilityGraph := NewVisibilityGraph(start, start)
+	visibilityGraph.base = s.edges
+	s.connect(&visibilityGraph, start)
+	distances, predecessors, _ := visibilityGraph.search(nil)
+
+	roots := make([]pathMapRoot, 0, len(distances))
+	for v, d := range distances {
+		roots = append(roots, pathMapRoot{vertex: v, distance: d})
+	}
+	slices.SortFunc(roots, func(a, b pathMapRoot) int {
+		if a.distance != b.distance {
+			if a.distance < b.distance {
+				return -1
+			}
+			return 1
+		}
+		return comparePoints(a.vertex, b.vertex)
+	})
+
+	s.parallelFor(len(roots), func(index int) {
+		roots[index].region = newVisibleRegion(roots[index].vertex, s.obstacles, s.vertexInfo)
+	})
+
+	vertices := []Point{start}
+	for _, obstacle := range s.obstacles {
+		vertices = append(vertices, obstacle.vertices()...)
+	}
+
+	return &ShortestPathMap{
+		S:            start,
+		scene:        s,
+		predecessors: predecessors,
+		roots:        roots,
+		grid:         newLocationGrid(newBoundingBox(vertices), roots, s.parallelFor),
+	}
+}
+
+// Locate returns the last vertex on the shortest path from S to q, which is S
+// itself when q is visible from S, and the length of that path. It reports
+// false when q lies inside an obstacle or cannot be reached from S.
+//
+// The cell of the grid containing q is found in constant time and only the
+// roots listed for it are tested, each by a binary search over its
+// directions, in order of the least distance they could give until no closer
+// one can remain. Points outside the bounding box of the obstacles, which only
+// scenes without a boundary have, are tested against every root.
+func (spm *ShortestPathMap) Locate(q Point) (Point, float64, bool) {
+	var root *pathMapRoot
+	best := math.Inf(1)
+	test := func(candidate *pathMapRoot) {
+		d := candidate.distance + candidate.vertex.Distance(q)
+		if d < best && candidate.region.contains(q) {
+			root, best = candidate, d
+		}
+	}
+
+	if candidates, ok := spm.grid.candidates(q); ok {
+		for _, candidate := range candidates {
+			if candidate.bound >= best {
+				break
+			}
+			test(&spm.roots[candidate.root])
+		}
+	} else {
+		for i := range spm.roots {
+			if spm.roots[i].distance >= best {
+				break
+			}
+			test(&spm.roots[i])
+		}
+	}
+
+	if root == nil {
+		return Point{}, 0, false
+	}
+	return root.vertex, best, true
+}
+
+// ShortestPath returns the shortest path from S to q and its length. When q
+// cannot be reached the error wraps ErrNoPath.
+func (spm *ShortestPathMap) ShortestPath(q Point) ([]Point, float64, error) {
+	root, length, ok := spm.Locate(q)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: no vertex reached from S sees %v", ErrNoPath, q)
+	}
+
+	path := []Point{q}
+	if root != q {
+		path = append(path, root)
+	}
+	for curr := root; curr != spm.S; {
+		curr = spm.predecessors[curr]
+		path = append(path, curr)
+	}
+	slices.Reverse(path)
+
+	return path, length, nil
+}
+
+// visibleRegion is the part of the plane visible from center. It is star
+// shaped around center, so it is described by the first obstacle edge met in
+// each angular interval between the obstacle vertices, nil where the view is
+// unobstructed.
+type visibleRegion struct {
+	center Point
+	// corner describes center when it is an obstacle vertex, whose own
+	// obstacle hides the directions between its edges
+	corner *vertexInfo
+	// directions holds a vertex on the direction starting each interval,
+	// in counter-clockwise order from the positive x axis
+	directions []Point
+	blockers   []*Segment
+}
+
+func newVisibleRegion(center Point, S []Obstacle, vertices map[Point]vertexInfo) visibleRegion {
+	pointToObstacle := makePointToObstacleMap(S)
+	sortedVertices := sortVerticesByAngle(center, verticesExcept(center, S))
+
+	region := visibleRegion{center: center}
+	if info, ok := vertices[center]; ok {
+		region.corner = &info
+	}
+	T := newSweep(center, S)
+	for i, wI := range sortedVertices {
+		T.SetRay(wI)
+		advanceSweep(T, center, wI, pointToObstacle[wI])
+
+		// The interval starts once all the vertices in this direction have
+		// been passed
+		if i+1 < len(sortedVertices) && compareAngle(center, wI, sortedVertices[i+1]) == 0 {
+			continue
+		}
+
+		var blocker *Segment
+		if si, exists := T.GetLeftmostSegmentIntersection(); exists {
+			blocker = &si.segment
+		}
+		region.directions = append(region.directions, wI)
+		region.blockers = append(region.blockers, blocker)
+	}
+
+	return region
+}
+
+// polygon returns the outline of the region as a star shaped polygon around
+// its center, cut off by a circle enclosing the box where nothing blocks the
+// view, and whether its vertices are exact up to rounding. It is not where a
+// ray only grazes its blocker, in which case the polygon may cover more than
+// the region.
+func (r visibleRegion) polygon(box boundingBox) ([]Point, bool) {
+	radius := 2 * (maxDistance(r.center, box) + 1)
+	far := func(angle float64) Point {
+		return Point{r.center.X + radius*math.Cos(angle), r.center.Y + radius*math.Sin(angle)}
+	}
+	angle := func(p Point) float64 {
+		return math.Atan2(p.Y-r.center.Y, p.X-r.center.X)
+	}
+
+	if len(r.directions) == 0 {
+		return []Point{far(0), far(math.Pi / 2), far(math.Pi), far(3 * math.Pi / 2)}, true
+	}
+
+	var polygon []Point
+	exact := true
+	for i, from := range r.directions {
+		to := r.directions[(i+1)%len(r.directions)]
+		if blocker := r.blockers[i]; blocker != nil {
+			a, okA := rayLineIntersection(r.center, from, *blocker)
+			b, okB := rayLineIntersection(r.center, to, *blocker)
+			if okA && okB {
+				polygon = append(polygon, a, b)
+				continue
+			}
+			exact = false
+		}
+
+		// Around the interval in steps of at most a right angle, so that the
+		// chords between the far points stay outside the box
+		start, end := angle(from), angle(to)
+		for end <= start {
+			end += 2 * math.Pi
+		}
+		steps := int(math.Ceil((end - start) / (math.Pi / 2)))
+		for k := 0; k <= steps; k++ {
+			polygon = append(polygon, far(start+(end-start)*float64(k)/float64(steps)))
+		}
+	}
+	return polygon, exact
+}
+
+// rayLineIntersection returns where the ray from p through q meets the line
+// through the segment, or false if it does not meet it ahead of p.
+func rayLineIntersection(p, q Point, segment Segment) (Point, bool) {
+	dx, dy := q.X-p.X, q.Y-p.Y
+	ex, ey := segment.end.X-segment.start.X, segment.end.Y-segment.start.Y
+	det := dx*ey - dy*ex
+	if det == 0 {
+		return Point{}, false
+	}
+	t := ((segment.start.X-p.X)*ey - (segment.start.Y-p.Y)*ex) / det
+	if t <= 0 || math.IsInf(t, 0) || math.IsNaN(t) {
+		return Point{}, false
+	}
+	return Point{p.X + t*dx, p.Y + t*dy}, true
+}
+
+// contains reports whether q is visible from the region's center.
+func (r visibleRegion) contains(q Point) bool {
+	if q == r.center {
+		return true
+	}
+	if r.corner != nil && r.corner.isInterior(orientation(r.corner.prev, r.center, q), orientation(r.center, r.corner.next, q)) {
+		return false
+	}
+	if len(r.directions) == 0 {
+		return true
+	}
+
+	// The interval containing q starts at the last direction not after q,
+	// wrapping around to the last interval
+	i := sort.Search(len(r.directions), func(i int) bool {
+		return compareAngle(r.center, r.directions[i], q) > 0
+	}) - 1
+	if i < 0 {
+		i = len(r.directions) - 1
+	}
+
+	blocker := r.blockers[i]
+	if blocker == nil {
+		return true
+	}
+	return orientation(blocker.start, blocker.end, q) != -orientation(blocker.start, blocker.end, r.center)
+}
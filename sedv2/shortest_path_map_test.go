@@ -0,0 +1,66 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestShortestPathMapMatchesFindShortestPath(t *testing.T) {
+	for seed := uint64(0); seed < 9; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		obstacles, _ := randomScene(r, int(seed%2))
+		m := NewMap(Point{}, Point{})
+		if err := m.AddObstacles(obstacles[:len(obstacles)-1]...); err != nil {
+			t.Fatal(err)
+		}
+		boundary := obstacles[len(obstacles)-1].Vertices
+		if err := m.SetBoundary(boundary...); err != nil {
+			t.Fatal(err)
+		}
+		free := func() Point {
+			for {
+				p := Point{-20 + 340*r.Float64(), -20 + 340*r.Float64()}
+				if !slices.ContainsFunc(m.Scene().obstacles, func(obstacle Obstacle) bool { return obstacle.Contains(p) }) {
+					return p
+				}
+			}
+		}
+		m.S = free()
+		pathMap := m.ShortestPathMap()
+
+		for query := 0; query < 100; query++ {
+			q := free()
+			if query%10 == 0 {
+				// Vertices lie on region boundaries
+				vertices := m.Scene().obstacles[r.IntN(len(obstacles)-1)].Vertices
+				q = vertices[r.IntN(len(vertices))]
+			}
+			step := fmt.Sprintf("seed %d S %v q %v", seed, m.S, q)
+
+			path, length, err := pathMap.ShortestPath(q)
+			pathLength := 0.0
+			for i := 0; i+1 < len(path); i++ {
+				pathLength += path[i].Distance(path[i+1])
+			}
+			m.T = q
+			_, wantErr := m.FindShortestPath()
+			want := m.Results.Length
+			switch {
+			case wantErr != nil:
+				if !errors.Is(err, ErrNoPath) {
+					t.Errorf("%s: got a path of length %v, want %v", step, length, wantErr)
+				}
+			case err != nil:
+				t.Errorf("%s: %v, want a path of length %v", step, err, want)
+			case math.Abs(length-want) > 1e-9*want:
+				t.Errorf("%s: got %v of length %v, want %v of length %v", step, path, length, m.Results.Path, want)
+			case math.Abs(pathLength-length) > 1e-9*length:
+				t.Errorf("%s: path %v has length %v, reported %v", step, path, pathLength, length)
+			}
+		}
+	}
+}
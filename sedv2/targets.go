@@ -0,0 +1,89 @@
+package sedv2
+
+import (
+	"fmt"
+	"math"
+)
+
+// TargetsResult holds the distances and shortest paths from S to each of the
+// map's Targets, in the same order. Unreachable targets are at an infinite
+// distance and have a nil path. Nearest is the index of the nearest target, -1
+// when none can be reached.
+type TargetsResult struct {
+	Nearest   int
+	Distances []float64
+	Paths     [][]Point
+}
+
+// FindNearestTarget connects S and every one of the map's Targets to the map's
+// Scene and runs Dijkstra's algorithm once from S to find the distance and the
+// shortest path to each target. The path to the nearest target becomes
+// Results.Path. If no target can be reached the error wraps ErrNoPath; if S or
+// a target lies outside the map's boundary it wraps ErrOutsideBoundary.
+func (m *Map) FindNearestTarget() (TargetsResult, error) {
+	m.Results = Results{}
+	if err := m.checkInside("S", m.S); err != nil {
+		return TargetsResult{}, err
+	}
+	for i, target := range m.Targets {
+		if err := m.checkInside(fmt.Sprintf("target %d", i), target); err != nil {
+			return TargetsResult{}, err
+		}
+	}
+	if len(m.Targets) == 0 {
+		return TargetsResult{Nearest: -1}, fmt.Errorf("%w: the map has no targets", ErrNoPath)
+	}
+
+	visibilityGraph := m.Scene().targetsGraph(m.S, m.Targets)
+	m.Results.VisibilityGraph = &visibilityGraph
+	distanceMap, predecessorMap, expanded := visibilityGraph.search(nil)
+	m.Results.Expanded = expanded
+
+	result := TargetsResult{
+		Nearest:   -1,
+		Distances: make([]float64, len(m.Targets)),
+		Paths:     make([][]Point, len(m.Targets)),
+	}
+	for i, target := range m.Targets {
+		distance, reached := distanceMap[target]
+		if !reached {
+			result.Distances[i] = math.Inf(1)
+			continue
+		}
+		result.Distances[i] = distance
+		result.Paths[i] = visibilityGraph.pathTo(predecessorMap, target)
+		if result.Nearest < 0 || distance < result.Distances[result.Nearest] {
+			result.Nearest = i
+		}
+	}
+
+	if result.Nearest < 0 {
+		return result, fmt.Errorf("%w: none of the %d targets can be reached", ErrNoPath, len(m.Targets))
+	}
+	m.Results.Path = result.Paths[result.Nearest]
+	m.Results.Length = result.Distances[result.Nearest]
+	return result, nil
+}
+
+// targetsGraph returns the visibility graph of the scene with start and every
+// target added, its T being the first target.
+func (s *Scene) targetsGraph(start Point, targets []Point) VisibilityGraph {
+	visibilityGraph := NewVisibilityGraph(start, targets[0])
+	visibilityGraph.base = s.edges
+	s.connect(&visibilityGraph, start)
+
+	connected := map[Point]bool{start: true}
+	for _, target := range targets {
+		if connected[target] {
+			continue
+		}
+		connected[target] = true
+		s.connect(&visibilityGraph, target)
+		if s.seesDirectly(start, target) {
+			visibilityGraph.AddEdges(start, []Point{target})
+			visibilityGraph.AddEdges(target, []Point{start})
+		}
+	}
+
+	return visibilityGraph
+}
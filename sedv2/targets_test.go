@@ -0,0 +1,78 @@
+package sedv2
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// targetsMap returns a map whose S has a wall right in front of it and a
+// courtyard to its side, with a target behind the wall, one farther away in
+// the open and one in the courtyard, which cannot be reached.
+func targetsMap(t *testing.T) *Map {
+	t.Helper()
+	m := NewMap(Point{0, 50}, Point{})
+	err := m.AddObstacles(
+		Obstacle{Vertices: []Point{{10, 0}, {20, 0}, {20, 100}, {10, 100}}},
+		Obstacle{
+			Vertices: []Point{{-35, 65}, {-5, 65}, {-5, 95}, {-35, 95}},
+			Holes:    [][]Point{{{-30, 70}, {-10, 70}, {-10, 90}, {-30, 90}}},
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Targets = []Point{{30, 50}, {-60, 50}, {-20, 80}}
+	return m
+}
+
+func TestFindNearestTargetByPathLength(t *testing.T) {
+	m := targetsMap(t)
+	result, err := m.FindNearestTarget()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The target behind the wall is the nearest in a straight line, but
+	// farther along a path than the one in the open
+	if result.Nearest != 1 {
+		t.Errorf("nearest target %d at distances %v, want 1", result.Nearest, result.Distances)
+	}
+	if !slices.Equal(m.Results.Path, result.Paths[1]) || m.Results.Length != result.Distances[1] {
+		t.Errorf("Results hold path %v of length %v, want the path to target 1 %v of length %v",
+			m.Results.Path, m.Results.Length, result.Paths[1], result.Distances[1])
+	}
+	if !math.IsInf(result.Distances[2], 1) || result.Paths[2] != nil {
+		t.Errorf("target in the courtyard at distance %v along %v, want it unreachable", result.Distances[2], result.Paths[2])
+	}
+
+	for i, target := range m.Targets[:2] {
+		m.T = target
+		path, err := m.FindShortestPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(result.Distances[i]-m.Results.Length) > 1e-9 || !slices.Equal(result.Paths[i], path) {
+			t.Errorf("target %d: path %v of length %v, want the shortest path %v of length %v",
+				i, result.Paths[i], result.Distances[i], path, m.Results.Length)
+		}
+	}
+}
+
+func TestFindNearestTargetUnreachable(t *testing.T) {
+	m := targetsMap(t)
+	m.Targets = m.Targets[2:]
+	result, err := m.FindNearestTarget()
+	if !errors.Is(err, ErrNoPath) || result.Nearest != -1 {
+		t.Errorf("nearest target %d and error %v, want -1 and ErrNoPath", result.Nearest, err)
+	}
+	if m.Results.Path != nil {
+		t.Errorf("Results hold path %v, want none", m.Results.Path)
+	}
+
+	m.Targets = nil
+	if _, err := m.FindNearestTarget(); !errors.Is(err, ErrNoPath) {
+		t.Errorf("got error %v without targets, want ErrNoPath", err)
+	}
+}
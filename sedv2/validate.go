@@ -0,0 +1,176 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	// ErrTooFewVertices is reported for a ring with fewer than three distinct
+	// vertices.
+	ErrTooFewVertices = errors.New("sedv2: ring has fewer than three vertices")
+	// ErrSelfIntersecting is reported for two edges of an obstacle that cross,
+	// touch or overlap without being neighbours on the same ring.
+	ErrSelfIntersecting = errors.New("sedv2: obstacle edges intersect")
+	// ErrHoleOutside is reported for a hole that does not lie inside the
+	// outline of its obstacle, or lies inside another hole.
+	ErrHoleOutside = errors.New("sedv2: hole outside of its obstacle")
+	// ErrBoundingObstacle is reported for an obstacle marked Bounding that is
+	// added as an obstacle. The map's boundary is set with SetBoundary.
+	ErrBoundingObstacle = errors.New("sedv2: bounding obstacle added as an obstacle")
+)
+
+// ObstacleError describes why an obstacle was rejected. Index is the position
+// of the obstacle in the call that added it, Ring is 0 for its outline and i+1
+// for its i-th hole, and Vertices are the vertices at fault: the endpoints of
+// the intersecting edges, or the whole ring.
+type ObstacleError struct {
+	Index    int
+	Ring     int
+	Vertices []Point
+	Err      error
+}
+
+func (e *ObstacleError) Error() string {
+	return fmt.Sprintf("obstacle %d, ring %d: %v at %v", e.Index, e.Ring, e.Err, e.Vertices)
+}
+
+func (e *ObstacleError) Unwrap() error {
+	return e.Err
+}
+
+// NormalizeObstacles validates the obstacles and returns copies of them with
+// repeated consecutive vertices removed, outlines counter-clockwise and holes
+// clockwise. If any obstacle is invalid it returns nil and every problem found,
+// each one an *ObstacleError.
+func NormalizeObstacles(obstacles ...Obstacle) ([]Obstacle, error) {
+	normalized := make([]Obstacle, len(obstacles))
+	var errs []error
+	for i, obstacle := range obstacles {
+		var obstacleErrs []error
+		normalized[i], obstacleErrs = obstacle.normalize()
+		for _, err := range obstacleErrs {
+			err.(*ObstacleError).Index = i
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return normalized, nil
+}
+
+// normalize returns a normalized copy of the obstacle and the problems found
+// in it, their Index left to the caller.
+func (o Obstacle) normalize() (Obstacle, []error) {
+	var errs []error
+	rings := o.rings()
+	for r := range rings {
+		ring := compactRing(rings[r])
+		if len(ring) < 3 {
+			errs = append(errs, &ObstacleError{Ring: r, Vertices: rings[r], Err: ErrTooFewVertices})
+		}
+		rings[r] = ring
+	}
+	if len(errs) > 0 {
+		return Obstacle{}, errs
+	}
+
+	errs = append(errs, ringIntersections(rings)...)
+	for r := 1; r < len(rings) && len(errs) == 0; r++ {
+		if !isInsideRing(rings[r][0], rings[0]) {
+			errs = append(errs, &ObstacleError{Ring: r, Vertices: rings[r], Err: ErrHoleOutside})
+		}
+		for h := 1; h < len(rings); h++ {
+			if h != r && isInsideRing(rings[r][0], rings[h]) {
+				errs = append(errs, &ObstacleError{Ring: r, Vertices: rings[r], Err: ErrHoleOutside})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return Obstacle{}, errs
+	}
+
+	// Orient the outline counter-clockwise and the holes clockwise
+	for r, ring := range rings {
+		if (ringArea(ring) < 0) == (r == 0) {
+			slices.Reverse(ring)
+		}
+	}
+	o.Vertices, o.Holes = rings[0], nil
+	if len(rings) > 1 {
+		o.Holes = rings[1:]
+	}
+	return o, nil
+}
+
+// compactRing returns a copy of the ring without repeated consecutive
+// vertices, the last and the first vertex included.
+func compactRing(ring []Point) []Point {
+	ring = slices.Compact(slices.Clone(ring))
+	for len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	return ring
+}
+
+// ringIntersections reports every pair of edges of the rings that meet other
+// than at the vertex shared by neighbours, every vertex at which a ring doubles
+// back along itself and every vertex visited twice.
+func ringIntersections(rings [][]Point) []error {
+	var errs []error
+	visited := make(map[Point]bool)
+	for r, ring := range rings {
+		for _, v := range ring {
+			if visited[v] {
+				errs = append(errs, &ObstacleError{Ring: r, Vertices: []Point{v}, Err: ErrSelfIntersecting})
+			}
+			visited[v] = true
+		}
+	}
+
+	type ringEdge struct {
+		ring, index int
+		Segment
+	}
+	var edges []ringEdge
+	for r, ring := range rings {
+		for i := range ring {
+			edges = append(edges, ringEdge{r, i, Segment{ring[i], ring[(i+1)%len(ring)]}})
+		}
+	}
+
+	for i, e := range edges {
+		n := len(rings[e.ring])
+		prev := rings[e.ring][(e.index+n-1)%n]
+		if orientation(prev, e.start, e.end) == 0 && !isBetween(prev, e.end, e.start) {
+			errs = append(errs, &ObstacleError{Ring: e.ring, Vertices: []Point{prev, e.start, e.end}, Err: ErrSelfIntersecting})
+		}
+
+		for _, f := range edges[i+1:] {
+			neighbours := e.ring == f.ring && (f.index == (e.index+1)%n || e.index == (f.index+1)%n)
+			if !neighbours && doSegmentsIntersect(e.start, e.end, f.start, f.end) {
+				errs = append(errs, &ObstacleError{Ring: e.ring, Vertices: []Point{e.start, e.end, f.start, f.end}, Err: ErrSelfIntersecting})
+			}
+		}
+	}
+	return errs
+}
+
+// isInsideRing reports whether p lies inside the ring by the even-odd rule.
+func isInsideRing(p Point, ring []Point) bool {
+	return Obstacle{Vertices: ring}.Contains(p)
+}
+
+// ringArea returns the signed area of the ring, positive when it is
+// counter-clockwise.
+func ringArea(ring []Point) float64 {
+	area := 0.0
+	for i := 0; i < len(ring); i++ {
+		a := ring[i]
+		b := ring[(i+1)%len(ring)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area / 2
+}
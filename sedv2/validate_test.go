@@ -0,0 +1,118 @@
+package sedv2
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestNormalizeObstaclesErrors(t *testing.T) {
+	square := []Point{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	for _, test := range []struct {
+		name     string
+		obstacle Obstacle
+		ring     int
+		err      error
+	}{
+		{"two vertices", Obstacle{Vertices: []Point{{0, 0}, {1, 0}}}, 0, ErrTooFewVertices},
+		{"two distinct vertices", Obstacle{Vertices: []Point{{0, 0}, {0, 0}, {1, 0}, {1, 0}, {0, 0}}}, 0, ErrTooFewVertices},
+		{"hole of two vertices", Obstacle{Vertices: square, Holes: [][]Point{{{1, 1}, {2, 2}}}}, 1, ErrTooFewVertices},
+		{"bow tie", Obstacle{Vertices: []Point{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}, 0, ErrSelfIntersecting},
+		{"figure eight", Obstacle{Vertices: []Point{{0, 0}, {2, 0}, {1, 1}, {2, 2}, {0, 2}, {1, 1}}}, 0, ErrSelfIntersecting},
+		{"doubling back", Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 2}, {4, 1}, {0, 2}}}, 0, ErrSelfIntersecting},
+		{"hole crossing the outline", Obstacle{Vertices: square, Holes: [][]Point{{{8, 4}, {12, 4}, {12, 6}, {8, 6}}}}, 0, ErrSelfIntersecting},
+		{"hole touching the outline", Obstacle{Vertices: square, Holes: [][]Point{{{10, 0}, {5, 5}, {8, 2}}}}, 1, ErrSelfIntersecting},
+		{"crossing holes", Obstacle{Vertices: square, Holes: [][]Point{
+			{{1, 1}, {5, 1}, {5, 5}, {1, 5}},
+			{{4, 4}, {8, 4}, {8, 8}, {4, 8}},
+		}}, 1, ErrSelfIntersecting},
+		{"hole outside", Obstacle{Vertices: square, Holes: [][]Point{{{20, 20}, {21, 20}, {21, 21}}}}, 1, ErrHoleOutside},
+		{"hole around the outline", Obstacle{Vertices: []Point{{4, 4}, {6, 4}, {6, 6}, {4, 6}}, Holes: [][]Point{square}}, 1, ErrHoleOutside},
+		{"hole inside a hole", Obstacle{Vertices: square, Holes: [][]Point{
+			{{1, 1}, {8, 1}, {8, 8}, {1, 8}},
+			{{3, 3}, {4, 3}, {4, 4}, {3, 4}},
+		}}, 2, ErrHoleOutside},
+	} {
+		// A valid obstacle first, so that the reported Index is not the default
+		normalized, err := NormalizeObstacles(Obstacle{Vertices: square}, test.obstacle)
+		if normalized != nil {
+			t.Errorf("%s: normalized to %v despite the error", test.name, normalized)
+		}
+		if err == nil {
+			t.Errorf("%s: no error", test.name)
+			continue
+		}
+		var obstacleErr *ObstacleError
+		if !errors.As(err, &obstacleErr) {
+			t.Errorf("%s: error %v is not an *ObstacleError", test.name, err)
+			continue
+		}
+		if obstacleErr.Index != 1 || obstacleErr.Ring != test.ring || !errors.Is(obstacleErr, test.err) {
+			t.Errorf("%s: got %v, want obstacle 1, ring %d: %v", test.name, obstacleErr, test.ring, test.err)
+		}
+		for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s: got %v, want only %v", test.name, err, test.err)
+			}
+		}
+	}
+}
+
+func TestNormalizeObstacles(t *testing.T) {
+	for _, test := range []struct {
+		name           string
+		obstacle, want Obstacle
+	}{
+		{
+			"normalized",
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 1}, {1, 2}, {2, 2}}}},
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 1}, {1, 2}, {2, 2}}}},
+		},
+		{
+			"clockwise outline",
+			Obstacle{Vertices: []Point{{0, 0}, {0, 4}, {4, 4}, {4, 0}}},
+			Obstacle{Vertices: []Point{{4, 0}, {4, 4}, {0, 4}, {0, 0}}},
+		},
+		{
+			"counter-clockwise hole",
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 1}, {2, 2}, {1, 2}}}},
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 2}, {2, 2}, {1, 1}}}},
+		},
+		{
+			"repeated vertices",
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 0}, {4, 0}, {4, 4}, {0, 0}}},
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}}},
+		},
+		{
+			"repeated vertices of a hole",
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 1}, {1, 1}, {1, 2}, {2, 2}, {1, 1}}}},
+			Obstacle{Vertices: []Point{{0, 0}, {4, 0}, {4, 4}, {0, 4}}, Holes: [][]Point{{{1, 1}, {1, 2}, {2, 2}}}},
+		},
+		{
+			// Only repeated vertices go, a vertex in the middle of an edge stays
+			"collinear vertices",
+			Obstacle{Vertices: []Point{{0, 0}, {2, 0}, {4, 0}, {4, 4}, {2, 2}}},
+			Obstacle{Vertices: []Point{{0, 0}, {2, 0}, {4, 0}, {4, 4}, {2, 2}}},
+		},
+		{
+			"clockwise boundary",
+			Obstacle{Vertices: []Point{{0, 0}, {0, 4}, {4, 4}, {4, 4}, {4, 0}}, Bounding: true},
+			Obstacle{Vertices: []Point{{4, 0}, {4, 4}, {0, 4}, {0, 0}}, Bounding: true},
+		},
+	} {
+		vertices := slices.Clone(test.obstacle.Vertices)
+		normalized, err := NormalizeObstacles(test.obstacle)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		got := normalized[0]
+		if !slices.Equal(got.Vertices, test.want.Vertices) || got.Bounding != test.want.Bounding ||
+			!slices.EqualFunc(got.Holes, test.want.Holes, slices.Equal) {
+			t.Errorf("%s: normalized to %v, want %v", test.name, got, test.want)
+		}
+		if !slices.Equal(test.obstacle.Vertices, vertices) {
+			t.Errorf("%s: the given vertices changed to %v", test.name, test.obstacle.Vertices)
+		}
+	}
+}
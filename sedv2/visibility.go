@@ -6,76 +6,32 @@ import (
 )
 
 func GetVisibilityGraph(S []Obstacle, start, target Point) VisibilityGraph {
-	allVertices := make([]Point, 0)
-
-	allVertices = append(allVertices, start, target)
-	for _, obstacle := range S {
-		allVertices = append(allVertices, obstacle.Vertices...)
-	}
-
-	visibilityGraph := NewVisibilityGraph(start, target)
-
-	for _, v := range allVertices {
-		W := VisibleVertices(v, S)
-		if W == nil {
-			continue
-		}
-
-		visibilityGraph.AddEdges(v, W)
-
-		for _, w := range W {
-			visibilityGraph.AddEdges(w, []Point{v})
-		}
-	}
-
-	for _, obstacle := range S {
-		for i := 0; i < len(obstacle.Vertices); i++ {
-			vstart := obstacle.Vertices[i]
-			vend := obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
-			visibilityGraph.AddEdges(vstart, []Point{vend})
-			visibilityGraph.AddEdges(vend, []Point{vstart})
-		}
-	}
+	return GetVisibilityGraphWithOptions(S, start, target, GraphOptions{})
+}
 
+func GetVisibilityGraphWithOptions(S []Obstacle, start, target Point, options GraphOptions) VisibilityGraph {
+	visibilityGraph := PrepareScene(S, options).VisibilityGraph(start, target)
+	visibilityGraph.flatten()
 	return visibilityGraph
 }
 
 func sortVerticesByAngle(p Point, vertices []Point) []Point {
-	type Vertex struct {
-		Point
-		Angle    float32
-		Distance float32
-	}
-
-	sortedVertices := make([]Vertex, len(vertices))
-	for i, v := range vertices {
-		angle := p.Angle(v)
-		distance := p.Distance(v)
-		sortedVertices[i] = Vertex{v, angle, distance}
-	}
+	sortedPoints := slices.Clone(vertices)
 
-	vertexComparator := func(a, b Vertex) int {
-		const epsilon = 1e-10
-		if math.Abs(float64(a.Angle-b.Angle)) < epsilon {
-			if a.Distance < b.Distance {
-				return -1
-			} else if a.Distance > b.Distance {
-				return 1
-			}
+	vertexComparator := func(a, b Point) int {
+		if order := compareAngle(p, a, b); order != 0 {
+			return order
+		}
+		if a == b {
 			return 0
 		}
-		if a.Angle < b.Angle {
+		if isBetween(p, b, a) {
 			return -1
 		}
 		return 1
 	}
 
-	slices.SortFunc(sortedVertices, vertexComparator)
-
-	sortedPoints := make([]Point, len(sortedVertices))
-	for i, v := range sortedVertices {
-		sortedPoints[i] = v.Point
-	}
+	slices.SortFunc(sortedPoints, vertexComparator)
 
 	return sortedPoints
 }
@@ -83,7 +39,7 @@ func sortVerticesByAngle(p Point, vertices []Point) []Point {
 func makePointToObstacleMap(S []Obstacle) map[Point]*Obstacle {
 	pointToObstacle := make(map[Point]*Obstacle)
 	for i := range S {
-		for _, vertex := range S[i].Vertices {
+		for _, vertex := range S[i].vertices() {
 			pointToObstacle[vertex] = &S[i]
 		}
 	}
@@ -94,42 +50,9 @@ func VisibleVertices(p Point, S []Obstacle) []Point {
 	pointToObstacle := makePointToObstacleMap(S)
 
 	// Sort the obstacle vertices according to the clockwise angle
-	allVertices := make([]Point, 0)
+	sortedVertices := sortVerticesByAngle(p, verticesExcept(p, S))
 
-	for _, obstacle := range S {
-		for _, vertex := range obstacle.Vertices {
-			if vertex == p {
-				continue
-			}
-			allVertices = append(allVertices, vertex)
-		}
-	}
-
-	sortedVertices := sortVerticesByAngle(p, allVertices)
-
-	T := NewSegmentTree(p)
-	pDir := Point{1, 0}
-	T.currRaydir = pDir
-	for _, obstacle := range S {
-		for i := 0; i < len(obstacle.Vertices); i++ {
-			start := obstacle.Vertices[i]
-			end := obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
-			//_, didIntersect := p.GetIntersectionWithRay(pDir, start, end)
-			//if didIntersect {
-			//	T.AddSegmentIntersection(Segment{
-			//		start: start,
-			//		end:   end,
-			//	})
-			//}
-			intersection, didIntersect := p.GetIntersectionWithRay(pDir, start, end)
-			if didIntersect && intersection != p {
-				T.AddSegmentIntersection(Segment{
-					start: start,
-					end:   end,
-				})
-			}
-		}
-	}
+	T := newSweep(p, S)
 
 	var W []Point
 
@@ -137,7 +60,7 @@ func VisibleVertices(p Point, S []Obstacle) []Point {
 	wasPrevVisible := false
 	for i, vertex := range sortedVertices {
 		wI := vertex
-		T.currRaydir = Point{wI.X - p.X, wI.Y - p.Y}
+		T.SetRay(wI)
 
 		if Visible(i, p, wIPrev, wI, pointToObstacle, T, wasPrevVisible) {
 			W = append(W, wI)
@@ -147,83 +70,112 @@ func VisibleVertices(p Point, S []Obstacle) []Point {
 			wIPrev = wI
 		}
 
-		obstacle := pointToObstacle[wI]
+		advanceSweep(T, p, wI, pointToObstacle[wI])
 
-		other1, other2 := Point{}, Point{}
-		for j := 0; j < len(obstacle.Vertices); j++ {
-			start := obstacle.Vertices[j]
-			end := obstacle.Vertices[(j+1)%len(obstacle.Vertices)]
+		wIPrev = wI
+	}
 
-			if end == wI {
-				other2 = start
+	return W
+}
+
+// verticesExcept returns the obstacle vertices other than p.
+func verticesExcept(p Point, S []Obstacle) []Point {
+	vertices := make([]Point, 0)
+	for _, obstacle := range S {
+		for _, vertex := range obstacle.vertices() {
+			if vertex == p {
+				continue
 			}
+			vertices = append(vertices, vertex)
+		}
+	}
+	return vertices
+}
 
-			if start == wI {
-				other1 = end
+// newSweep returns the status of a rotational sweep around p holding the
+// obstacle edges crossed by the ray leaving p in the positive x direction.
+func newSweep(p Point, S []Obstacle) *SegmentIntersectionTree {
+	T := NewSegmentTree(p)
+	T.SetRay(Point{p.X + 1 + math.Abs(p.X), p.Y})
+	for _, obstacle := range S {
+		for _, edge := range obstacle.edges() {
+			if crossesHorizontalRay(p, edge.start, edge.end) {
+				T.AddSegmentIntersection(edge)
 			}
+		}
+	}
+	return T
+}
 
+// advanceSweep updates the status of the sweep around p once the ray has
+// reached the vertex wI of obstacle: the edges of wI lying ahead of the ray
+// are added and those lying behind it are removed.
+func advanceSweep(T *SegmentIntersectionTree, p, wI Point, obstacle *Obstacle) {
+	other1, other2 := Point{}, Point{}
+	for _, edge := range obstacle.edges() {
+		if edge.end == wI {
+			other2 = edge.start
 		}
 
-		o1CP, o2CP := -crossProduct(p, wI, other1), -crossProduct(p, wI, other2)
-		if o1CP < 0 && other1 != p {
-			T.AddSegmentIntersection(Segment{
+		if edge.start == wI {
+			other1 = edge.end
+		}
+
+	}
+
+	o1CP, o2CP := -orientation(p, wI, other1), -orientation(p, wI, other2)
+	if o1CP < 0 && other1 != p {
+		T.AddSegmentIntersection(Segment{
+			start: wI,
+			end:   other1,
+		})
+	} else if o1CP > 0 && other1 != p {
+		T.RemoveSegmentIntersection(SegmentIntersection{
+			segment: Segment{
 				start: wI,
 				end:   other1,
-			})
-		} else if o1CP > 0 && other1 != p {
-			T.RemoveSegmentIntersection(SegmentIntersection{
-				segment: Segment{
-					start: wI,
-					end:   other1,
-				},
-			})
-		}
+			},
+		})
+	}
 
-		if o2CP < 0 && other2 != p {
-			T.AddSegmentIntersection(Segment{
+	if o2CP < 0 && other2 != p {
+		T.AddSegmentIntersection(Segment{
+			start: other2,
+			end:   wI,
+		})
+	} else if o2CP > 0 && other2 != p {
+		T.RemoveSegmentIntersection(SegmentIntersection{
+			segment: Segment{
 				start: other2,
 				end:   wI,
-			})
-		} else if o2CP > 0 && other2 != p {
-			T.RemoveSegmentIntersection(SegmentIntersection{
-				segment: Segment{
-					start: other2,
-					end:   wI,
-				},
-			})
-		}
-
-		wIPrev = wI
+			},
+		})
 	}
-
-	return W
 }
 
+// intersectsObstacle reports whether the segment from p to the vertex wI of
+// obstacle is blocked by it, by the same rules as the rotation tree: crossing
+// an edge, or passing a vertex into the obstacle, blocks it, while running
+// along an edge or touching a vertex from outside does not.
 func intersectsObstacle(p, wI Point, obstacle *Obstacle) bool {
-	for i := 0; i < len(obstacle.Vertices); i++ {
-		a := obstacle.Vertices[i]
-		b := obstacle.Vertices[(i+1)%len(obstacle.Vertices)]
-		if (a != wI) && (b != wI) {
-			intersected := doSegmentsIntersect(p, wI, a, b)
-			if intersected {
-				return true
-			}
+	vertices := makeVertexInfoMap([]Obstacle{*obstacle})
+	for _, edge := range obstacle.edges() {
+		if edge.start != wI && edge.end != wI && blocksSegment(edge, p, wI, vertices) {
+			return true
 		}
 	}
-	return false
+
+	// Without crossing an edge the segment is either entirely inside the
+	// obstacle or entirely outside of it, which the way it leaves wI tells
+	return entersObstacle(wI, p, vertices) || entersObstacle(p, wI, vertices)
 }
 
 func Visible(i int, p, wIPrev, wI Point, pointToObstacle map[Point]*Obstacle, T *SegmentIntersectionTree, wasPrevVisible bool) bool {
 	obstacle, ok := pointToObstacle[wI]
-	obstacleP, okP := pointToObstacle[p]
 	if ok && intersectsObstacle(p, wI, obstacle) {
 		return false
 	}
 
-	if okP && ok && obstacleP == obstacle {
-		return false
-	}
-
 	if i == 0 || !wIPrev.OnSegment(p, wI) {
 		s, exists := T.GetLeftmostSegmentIntersection()
 		if exists {
@@ -5,12 +5,17 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"image/color"
+	"math"
+	"slices"
 )
 
 type VisibilityGraph struct {
 	AdjacencyMap map[Point][]Point
 	S            Point
 	T            Point
+	// base holds edges shared with other graphs of the same Scene. It is never
+	// modified; AddEdges only writes to AdjacencyMap.
+	base map[Point][]Point
 }
 
 func NewVisibilityGraph(s, t Point) VisibilityGraph {
@@ -25,12 +30,14 @@ func (vg *VisibilityGraph) Draw() fyne.CanvasObject {
 	objects := []fyne.CanvasObject{}
 
 	// Draw the edges of the visibility graph
-	for start, neighbors := range vg.AdjacencyMap {
-		for _, end := range neighbors {
-			line := canvas.NewLine(color.RGBA{0, 0, 255, 255}) // Blue color for edges
-			line.Position1 = fyne.NewPos(start.X, start.Y)
-			line.Position2 = fyne.NewPos(end.X, end.Y)
-			objects = append(objects, line)
+	for _, adjacencyMap := range []map[Point][]Point{vg.base, vg.AdjacencyMap} {
+		for start, neighbors := range adjacencyMap {
+			for _, end := range neighbors {
+				line := canvas.NewLine(color.RGBA{0, 0, 255, 255}) // Blue color for edges
+				line.Position1 = start.toPosition()
+				line.Position2 = end.toPosition()
+				objects = append(objects, line)
+			}
 		}
 	}
 
@@ -48,13 +55,13 @@ func (vg *VisibilityGraph) Draw() fyne.CanvasObject {
 		// Draw the circle
 		circle := canvas.NewCircle(p.color)
 		circle.Resize(fyne.NewSize(5, 5))
-		circle.Move(fyne.NewPos(p.point.X-2.5, p.point.Y-2.5))
+		circle.Move(p.point.toPosition().SubtractXY(2.5, 2.5))
 		objects = append(objects, circle)
 
 		// Draw the label
 		text := canvas.NewText(p.label, color.Black)
 		text.TextSize = 12
-		text.Move(fyne.NewPos(p.point.X+5, p.point.Y-6)) // Positioning the text near the point
+		text.Move(p.point.toPosition().Add(fyne.NewPos(5, -6))) // Positioning the text near the point
 		objects = append(objects, text)
 	}
 
@@ -65,43 +72,176 @@ func (vg *VisibilityGraph) AddEdges(from Point, to []Point) {
 	vg.AdjacencyMap[from] = append(vg.AdjacencyMap[from], to...)
 }
 
-func (vg *VisibilityGraph) ShortestEuclideanDistance() (map[Point]float32, []Point) {
-	// Initialize the distance map and predecessor map
-	distanceMap := make(map[Point]float32)
-	predecessorMap := make(map[Point]Point)
+// Neighbors returns the vertices adjacent to v.
+func (vg *VisibilityGraph) Neighbors(v Point) []Point {
+	if len(vg.base[v]) == 0 {
+		return vg.AdjacencyMap[v]
+	}
+	return append(slices.Clip(vg.base[v]), vg.AdjacencyMap[v]...)
+}
+
+// Vertices returns every vertex that has at least one edge.
+func (vg *VisibilityGraph) Vertices() []Point {
+	vertices := make([]Point, 0, len(vg.base)+len(vg.AdjacencyMap))
+	for v := range vg.base {
+		vertices = append(vertices, v)
+	}
 	for v := range vg.AdjacencyMap {
-		distanceMap[v] = float32(1<<31 - 1)
+		if _, ok := vg.base[v]; !ok {
+			vertices = append(vertices, v)
+		}
+	}
+	return vertices
+}
+
+// flatten copies the shared edges into AdjacencyMap, detaching the graph from
+// its Scene.
+func (vg *VisibilityGraph) flatten() {
+	if vg.base == nil {
+		return
+	}
+	adjacencyMap := make(map[Point][]Point, len(vg.base)+len(vg.AdjacencyMap))
+	for _, v := range vg.Vertices() {
+		adjacencyMap[v] = vg.Neighbors(v)
+	}
+	vg.AdjacencyMap = adjacencyMap
+	vg.base = nil
+}
+
+// SearchAlgorithm selects the graph search used to find the shortest path.
+type SearchAlgorithm int
+
+const (
+	// Dijkstra computes the distance from S to every vertex of the graph.
+	Dijkstra SearchAlgorithm = iota
+	// AStar is guided towards T by the straight-line distance and stops as soon
+	// as T is settled.
+	AStar
+)
+
+// SearchResult is the outcome of a search from S to T. Expanded counts the
+// vertices the search settled, T included.
+type SearchResult struct {
+	Path     []Point
+	Length   float64
+	Expanded int
+}
+
+// ShortestEuclideanDistance runs Dijkstra's algorithm from S and returns the
+// distance to every vertex together with the shortest path from S to T. When T
+// cannot be reached the path is nil and the error is a *NoPathError.
+func (vg *VisibilityGraph) ShortestEuclideanDistance() (map[Point]float64, []Point, error) {
+	distanceMap, predecessorMap, _ := vg.search(nil)
+	path, err := vg.reconstructPath(predecessorMap)
+	return distanceMap, path, err
+}
+
+// ShortestPath finds the shortest path from S to T with the given algorithm.
+func (vg *VisibilityGraph) ShortestPath(algorithm SearchAlgorithm) (SearchResult, error) {
+	var heuristic func(Point) float64
+	if algorithm == AStar {
+		heuristic = func(v Point) float64 {
+			return v.Distance(vg.T)
+		}
+	}
+
+	distanceMap, predecessorMap, expanded := vg.search(heuristic)
+	path, err := vg.reconstructPath(predecessorMap)
+	if err != nil {
+		return SearchResult{Expanded: expanded}, err
+	}
+
+	return SearchResult{
+		Path:     path,
+		Length:   distanceMap[vg.T],
+		Expanded: expanded,
+	}, nil
+}
+
+// search runs Dijkstra's algorithm from S, or A* towards T when a heuristic is
+// given. The heuristic must be consistent, as the straight-line distance is.
+func (vg *VisibilityGraph) search(heuristic func(Point) float64) (map[Point]float64, map[Point]Point, int) {
+	return vg.searchFrom(vg.S, heuristic, nil)
+}
+
+// searchFrom runs the search from start instead of S, leaving out the edges
+// from v to u for which skip, if given, reports true.
+func (vg *VisibilityGraph) searchFrom(start Point, heuristic func(Point) float64, skip func(v, u Point) bool) (map[Point]float64, map[Point]Point, int) {
+	// Initialize the distance map and predecessor map, vertices missing from
+	// the distance map have not been reached yet
+	distanceMap := make(map[Point]float64)
+	predecessorMap := make(map[Point]Point)
+	distanceMap[start] = 0
+
+	distance := func(v Point) float64 {
+		if d, ok := distanceMap[v]; ok {
+			return d
+		}
+		return math.Inf(1)
+	}
+	estimate := func(v Point) float64 {
+		if heuristic == nil {
+			return distance(v)
+		}
+		return distance(v) + heuristic(v)
 	}
-	distanceMap[vg.S] = 0
 
 	// Initialize the priority queue
 	pq := NewPriorityQueue()
-	pq.PushPoint(vg.S, 0)
+	pq.PushPoint(start, estimate(start))
 
-	// ShortestEuclideanDistance's algorithm
+	settled := make(map[Point]bool)
 	for !pq.IsEmpty() {
 		v, _ := pq.PopPoint()
+		if settled[v] {
+			continue
+		}
+		settled[v] = true
+
+		if heuristic != nil && v == vg.T {
+			break
+		}
 
 		// Relax the edges
-		for _, u := range vg.AdjacencyMap[v] {
-			if distanceMap[v]+v.Distance(u) < distanceMap[u] {
+		for _, u := range vg.Neighbors(v) {
+			if skip != nil && skip(v, u) {
+				continue
+			}
+			if distanceMap[v]+v.Distance(u) < distance(u) {
 				distanceMap[u] = distanceMap[v] + v.Distance(u)
 				predecessorMap[u] = v
-				pq.PushPoint(u, distanceMap[u])
+				pq.PushPoint(u, estimate(u))
 			}
 		}
 	}
 
-	// Reconstruct the path from S to T
-	path := []Point{}
-	curr := vg.T
+	return distanceMap, predecessorMap, len(settled)
+}
 
-	// Traverse the path from T to S using the predecessor map
-	for curr != vg.S {
-		path = append([]Point{curr}, path...)
-		curr = predecessorMap[curr]
+func (vg *VisibilityGraph) reconstructPath(predecessorMap map[Point]Point) ([]Point, error) {
+	if _, reached := predecessorMap[vg.T]; !reached && vg.T != vg.S {
+		return nil, &NoPathError{Reachability: vg.Reachability()}
+	}
+
+	return vg.pathTo(predecessorMap, vg.T), nil
+}
+
+// pathTo returns the path from the start of the search to target, which must
+// have been reached.
+func (vg *VisibilityGraph) pathTo(predecessorMap map[Point]Point, target Point) []Point {
+	path := []Point{target}
+	curr := target
+
+	// Traverse the path from target to the start, the only vertex reached
+	// without a predecessor, using the predecessor map
+	for {
+		prev, ok := predecessorMap[curr]
+		if !ok {
+			break
+		}
+		path = append([]Point{prev}, path...)
+		curr = prev
 	}
-	path = append([]Point{vg.S}, path...)
 
-	return distanceMap, path
+	return path
 }
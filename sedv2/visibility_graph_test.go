@@ -0,0 +1,63 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestAStarMatchesDijkstra compares the two searches between random free
+// points: A* must find a path of the same length, settling no more vertices
+// than Dijkstra's algorithm, which settles every vertex it reaches. Stopping
+// at T, A* must settle fewer on the whole.
+func TestAStarMatchesDijkstra(t *testing.T) {
+	dijkstraExpanded, aStarExpanded := 0, 0
+	for seed := uint64(0); seed < 12; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		obstacles, overlapping := randomScene(r, int(seed%3))
+		scene := PrepareScene(obstacles, GraphOptions{MergeOverlapping: overlapping})
+		free := func() Point {
+			for {
+				p := Point{-20 + 340*r.Float64(), -20 + 340*r.Float64()}
+				if isPathClear([]Point{p}, scene.obstacles) {
+					return p
+				}
+			}
+		}
+
+		for query := 0; query < 20; query++ {
+			S, T := free(), free()
+			step := fmt.Sprintf("seed %d from %v to %v", seed, S, T)
+			visibilityGraph := scene.VisibilityGraph(S, T)
+			dijkstra, dijkstraErr := visibilityGraph.ShortestPath(Dijkstra)
+			aStar, aStarErr := visibilityGraph.ShortestPath(AStar)
+			if dijkstraErr != nil || aStarErr != nil {
+				if !errors.Is(dijkstraErr, ErrNoPath) || !errors.Is(aStarErr, ErrNoPath) {
+					t.Errorf("%s: Dijkstra reports %v, A* %v", step, dijkstraErr, aStarErr)
+				}
+				continue
+			}
+
+			if math.Abs(aStar.Length-dijkstra.Length) > 1e-9*dijkstra.Length {
+				t.Errorf("%s: A* finds %v of length %v, Dijkstra %v of length %v", step, aStar.Path, aStar.Length, dijkstra.Path, dijkstra.Length)
+			}
+			if math.Abs(pathLength(aStar.Path)-aStar.Length) > 1e-9*aStar.Length {
+				t.Errorf("%s: A* path %v has length %v, reported %v", step, aStar.Path, pathLength(aStar.Path), aStar.Length)
+			}
+			if aStar.Expanded > dijkstra.Expanded {
+				t.Errorf("%s: A* settles %d vertices, Dijkstra %d", step, aStar.Expanded, dijkstra.Expanded)
+			}
+			reachability := visibilityGraph.Reachability()
+			if reached := len(reachability.Components[reachability.SComponent]); dijkstra.Expanded != reached {
+				t.Errorf("%s: Dijkstra settles %d vertices, %d are reachable", step, dijkstra.Expanded, reached)
+			}
+			dijkstraExpanded += dijkstra.Expanded
+			aStarExpanded += aStar.Expanded
+		}
+	}
+	if aStarExpanded >= dijkstraExpanded {
+		t.Errorf("A* settles %d vertices in all, Dijkstra %d", aStarExpanded, dijkstraExpanded)
+	}
+}
@@ -0,0 +1,134 @@
+package sedv2
+
+import "slices"
+
+// VisibilityPolygon returns the part of the free space visible from p, a star
+// shaped polygon around p in counter-clockwise order. It is found by the same
+// rotational sweep as VisibleVertices: between two consecutive vertex
+// directions the view ends at the nearest edge the ray meets, and where the
+// ray grazes past a vertex the polygon follows it out to the farther edge.
+// The region is closed by the scene's boundary or, without one, by a box
+// around the obstacles and p, a quarter of its size away from them. A point
+// inside an obstacle sees nothing, so its polygon is empty; a point on an
+// obstacle's edge or vertex sees the free side only.
+func (s *Scene) VisibilityPolygon(p Point) []Point {
+	// The edges p lies on, with the side of the area they block
+	type sideEdge struct {
+		Segment
+		winding int
+	}
+	var onEdges []sideEdge
+	for _, obstacle := range s.obstacles {
+		for r, ring := range obstacle.rings() {
+			for i, start := range ring {
+				end := ring[(i+1)%len(ring)]
+				if p != start && p != end && orientation(start, end, p) == 0 && isBetween(start, end, p) {
+					onEdges = append(onEdges, sideEdge{Segment{start, end}, obstacle.winding(r)})
+				}
+			}
+		}
+	}
+	if _, ok := s.vertexInfo[p]; !ok && len(onEdges) == 0 {
+		for _, obstacle := range s.obstacles {
+			if obstacle.Contains(p) {
+				return nil
+			}
+		}
+	}
+
+	obstacles := s.obstacles
+	if !slices.ContainsFunc(obstacles, func(o Obstacle) bool { return o.Bounding }) {
+		points := []Point{p}
+		for _, obstacle := range obstacles {
+			points = append(points, obstacle.vertices()...)
+		}
+		box := newBoundingBox(points)
+		margin := max(box.max.X-box.min.X, box.max.Y-box.min.Y, 1) / 4
+		obstacles = append(slices.Clip(obstacles), Obstacle{
+			Vertices: []Point{
+				{box.min.X - margin, box.min.Y - margin},
+				{box.max.X + margin, box.min.Y - margin},
+				{box.max.X + margin, box.max.Y + margin},
+				{box.min.X - margin, box.max.Y + margin},
+			},
+			Bounding: true,
+		})
+	}
+
+	// Every interval between two directions has a blocker, as the boundary
+	// surrounds p, unless it leads into an obstacle p lies on, where the
+	// polygon only touches p
+	region := newVisibleRegion(p, obstacles, s.vertexInfo)
+	var polygon []Point
+	for i, from := range region.directions {
+		to := region.directions[(i+1)%len(region.directions)]
+		inside := intervalPoint(p, from, to)
+		blocked := region.corner != nil &&
+			region.corner.isInterior(orientation(region.corner.prev, p, inside), orientation(p, region.corner.next, inside))
+		for _, edge := range onEdges {
+			blocked = blocked || orientation(edge.start, edge.end, inside)*edge.winding > 0
+		}
+		blocker := region.blockers[i]
+		if blocked || blocker == nil {
+			polygon = append(polygon, p)
+			continue
+		}
+		polygon = append(polygon, rayHit(p, from, *blocker), rayHit(p, to, *blocker))
+	}
+
+	return simplifyRing(polygon)
+}
+
+// intervalPoint returns a point in the open angular interval around p from the
+// direction of from counter-clockwise to that of to, the whole turn if they
+// are the same.
+func intervalPoint(p, from, to Point) Point {
+	a, b := from.Distance(p), to.Distance(p)
+	u := Point{(from.X - p.X) / a, (from.Y - p.Y) / a}
+	v := Point{(to.X - p.X) / b, (to.Y - p.Y) / b}
+	switch turn := orientation(p, from, to); {
+	case turn > 0:
+		return Point{p.X + u.X + v.X, p.Y + u.Y + v.Y}
+	case turn < 0:
+		return Point{p.X - u.X - v.X, p.Y - u.Y - v.Y}
+	case compareAngle(p, from, to) == 0:
+		return Point{p.X - u.X, p.Y - u.Y}
+	default:
+		return Point{p.X - u.Y, p.Y + u.X}
+	}
+}
+
+// rayHit returns the point where the ray from p through w meets the line
+// through the edge, the edge's endpoint if the ray passes through it.
+func rayHit(p, w Point, edge Segment) Point {
+	for _, end := range []Point{edge.start, edge.end} {
+		if orientation(p, w, end) == 0 && !isBetween(end, w, p) {
+			return end
+		}
+	}
+	dx, dy := w.X-p.X, w.Y-p.Y
+	ex, ey := edge.end.X-edge.start.X, edge.end.Y-edge.start.Y
+	t := ((edge.start.X-p.X)*ey - (edge.start.Y-p.Y)*ex) / (dx*ey - dy*ex)
+	return Point{p.X + t*dx, p.Y + t*dy}
+}
+
+// simplifyRing drops the repeated points of the ring and the points lying
+// straight between their neighbours.
+func simplifyRing(ring []Point) []Point {
+	ring = slices.Compact(ring)
+	for len(ring) > 1 && ring[0] == ring[len(ring)-1] {
+		ring = ring[:len(ring)-1]
+	}
+	for changed := true; changed && len(ring) > 3; {
+		changed = false
+		for i := 0; i < len(ring) && len(ring) > 3; i++ {
+			prev, next := ring[(i+len(ring)-1)%len(ring)], ring[(i+1)%len(ring)]
+			if orientation(prev, ring[i], next) == 0 && isBetween(prev, next, ring[i]) {
+				ring = slices.Delete(ring, i, i+1)
+				changed = true
+				i--
+			}
+		}
+	}
+	return ring
+}
@@ -0,0 +1,129 @@
+package sedv2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVisibilityPolygonOnBoundaryEdge(t *testing.T) {
+	m := NewMap(Point{}, Point{})
+	if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []Point{{50, 0}, {0, 30}, {0, 0}} {
+		polygon, err := m.FindVisibilityPolygon(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if area := ringArea(polygon); math.Abs(area-10000) > 1e-6 {
+			t.Errorf("%v: area = %v, want 10000; polygon %v", p, area, polygon)
+		}
+	}
+
+	if err := m.AddObstacles(Obstacle{Vertices: []Point{{40, 40}, {60, 40}, {60, 60}, {40, 60}}}); err != nil {
+		t.Fatal(err)
+	}
+	polygon, err := m.FindVisibilityPolygon(Point{50, 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	region := Obstacle{Vertices: polygon}
+	if region.Contains(Point{50, 50}) || region.Contains(Point{50, 10}) || !region.Contains(Point{50, 90}) {
+		t.Errorf("polygon %v from the obstacle's edge", polygon)
+	}
+}
+
+// rayCastArea returns the area visible from p by casting rays in the given
+// number of evenly spread directions, each reaching as far as the nearest
+// obstacle edge it crosses, as a sum of thin circular sectors. The directions
+// are turned slightly off any that pass through an obstacle vertex.
+func rayCastArea(p Point, obstacles []Obstacle, rays int) float64 {
+	var edges []Segment
+	for _, obstacle := range obstacles {
+		edges = append(edges, obstacle.edges()...)
+	}
+	step := 2 * math.Pi / float64(rays)
+	area := 0.0
+	for k := 0; k < rays; k++ {
+		angle := (float64(k) + 0.3183) * step
+		dx, dy := math.Cos(angle), math.Sin(angle)
+		reach := math.Inf(1)
+		for _, edge := range edges {
+			ex, ey := edge.end.X-edge.start.X, edge.end.Y-edge.start.Y
+			denominator := dx*ey - dy*ex
+			if denominator == 0 {
+				continue
+			}
+			// p + t(dx, dy) = start + s(ex, ey)
+			wx, wy := edge.start.X-p.X, edge.start.Y-p.Y
+			t := (wx*ey - wy*ex) / denominator
+			s := (wx*dy - wy*dx) / denominator
+			if t > 0 && 0 <= s && s <= 1 {
+				reach = min(reach, t)
+			}
+		}
+		area += reach * reach * step / 2
+	}
+	return area
+}
+
+func TestVisibilityPolygonGrazingRays(t *testing.T) {
+	boundary := Obstacle{Vertices: []Point{{-100, -100}, {200, -100}, {200, 200}, {-100, 200}}, Bounding: true}
+	obstacles := []Obstacle{
+		// The diagonal from the origin grazes a corner of each of these, on
+		// alternating sides
+		{Vertices: []Point{{10, 0}, {20, 0}, {20, 10}, {10, 10}}},
+		{Vertices: []Point{{25, 25}, {25, 35}, {15, 35}, {15, 25}}},
+		{Vertices: []Point{{40, 30}, {50, 30}, {50, 40}, {40, 40}}},
+		{Vertices: []Point{{60, 60}, {60, 75}, {45, 75}, {45, 60}}},
+	}
+	// Staggered boxes on a grid, whose corners line up with each other and
+	// with the lattice points looked from
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 3; j++ {
+			x, y := float64(-80+20*i+10*(j%2)), float64(100+25*j)
+			obstacles = append(obstacles, Obstacle{Vertices: []Point{{x, y}, {x + 10, y}, {x + 10, y + 10}, {x, y + 10}}})
+		}
+	}
+	scene := PrepareScene(append(obstacles, boundary), GraphOptions{})
+
+	for _, p := range []Point{{0, 0}, {-40, 90}, {-35, 120}, {30, 10}, {5, 5}, {-90, 100}} {
+		polygon := scene.VisibilityPolygon(p)
+		want := rayCastArea(p, append(obstacles, boundary), 200000)
+		if got := ringArea(polygon); math.Abs(got-want) > 1e-3*want {
+			t.Errorf("from %v: got area %v, ray casting gives %v; polygon %v", p, got, want, polygon)
+		}
+	}
+}
+
+func TestVisibilityPolygonInsideObstacle(t *testing.T) {
+	m := NewMap(Point{}, Point{})
+	if err := m.SetBoundary(Point{0, 0}, Point{100, 0}, Point{100, 100}, Point{0, 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddObstacles(Obstacle{
+		Vertices: []Point{{20, 20}, {80, 20}, {80, 80}, {20, 80}},
+		Holes:    [][]Point{{{40, 40}, {40, 60}, {60, 60}, {60, 40}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []Point{{30, 30}, {70, 50}, {25, 75}} {
+		polygon, err := m.FindVisibilityPolygon(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if polygon != nil || m.Results.VisibilityPolygon != nil {
+			t.Errorf("%v inside the obstacle: got polygon %v, want none", p, polygon)
+		}
+	}
+
+	// The hole is free space, seen whole from inside it
+	polygon, err := m.FindVisibilityPolygon(Point{50, 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if area := ringArea(polygon); math.Abs(area-400) > 1e-9 {
+		t.Errorf("inside the hole: got area %v, want 400; polygon %v", area, polygon)
+	}
+}
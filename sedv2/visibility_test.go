@@ -0,0 +1,58 @@
+package sedv2
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestVisibilityGraphAtLargeCoordinates builds scenes of boxes lined up on a
+// grid around 1e7, their corners moved by a few units in the last place so
+// that many of them are nearly collinear, and compares their graphs with the
+// graphs of the same scenes scaled down by a power of two, and the graphs of
+// the two builders with each other. Scaling by a power of two is exact, so the
+// graphs must match edge for edge.
+func TestVisibilityGraphAtLargeCoordinates(t *testing.T) {
+	const scale = 1 << 17
+	for seed := uint64(0); seed < 6; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		boxes := randomBoxes(r, 8)
+		large := make([]Obstacle, len(boxes))
+		small := make([]Obstacle, len(boxes))
+		for i, box := range boxes {
+			large[i].Vertices = make([]Point, len(box.Vertices))
+			small[i].Vertices = make([]Point, len(box.Vertices))
+			for k, v := range box.Vertices {
+				x, y := 1e7+v.X*scale, 1e7+v.Y*scale
+				for range r.IntN(4) {
+					x = math.Nextafter(x, math.Inf(2*r.IntN(2)-1))
+				}
+				for range r.IntN(4) {
+					y = math.Nextafter(y, math.Inf(2*r.IntN(2)-1))
+				}
+				large[i].Vertices[k] = Point{x, y}
+				small[i].Vertices[k] = Point{x / scale, y / scale}
+			}
+		}
+
+		for _, reduced := range []bool{false, true} {
+			options := GraphOptions{Reduced: reduced}
+			step := fmt.Sprintf("seed %d reduced %v", seed, reduced)
+			want := PrepareScene(small, options)
+			got := PrepareScene(large, options)
+			options.Builder = RotationTreeBuilder
+			checkSameEdges(t, step+" rotation tree", PrepareScene(large, options), got)
+
+			edges := make(map[Point][]Point, len(got.edges))
+			for v, neighbors := range got.edges {
+				v = Point{v.X / scale, v.Y / scale}
+				for _, w := range neighbors {
+					edges[v] = append(edges[v], Point{w.X / scale, w.Y / scale})
+				}
+			}
+			got.edges = edges
+			checkSameEdges(t, step, got, want)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package sedv2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// DefaultSteinerPoints is the number of Steiner points placed on every region
+// edge when no other number is given.
+const DefaultSteinerPoints = 3
+
+// ErrInvalidCost is reported for a region whose cost is not positive.
+var ErrInvalidCost = errors.New("sedv2: region cost must be positive")
+
+// Region is a traversable area where travelling costs Cost times the distance
+// travelled, such as mud with a Cost above 1 or a road with a Cost below 1.
+// Outside of every region travelling costs the distance. Where regions overlap
+// the one added last applies.
+type Region struct {
+	Vertices []Point
+	Cost     float64
+}
+
+// WeightedPath is the cheapest path found between S and T across the map's
+// regions. Regions lists the indices of the regions the path crosses in the
+// order it enters them.
+type WeightedPath struct {
+	Path    []Point
+	Cost    float64
+	Regions []int
+}
+
+// AddRegions validates the regions' outlines like those of obstacles and adds
+// the regions to the map. If any of them is invalid none are added and the
+// error lists every problem found, each one an *ObstacleError.
+func (m *Map) AddRegions(regions ...Region) error {
+	normalized := make([]Region, len(regions))
+	var errs []error
+	for i, region := range regions {
+		obstacle, obstacleErrs := Obstacle{Vertices: region.Vertices}.normalize()
+		if !(region.Cost > 0) {
+			obstacleErrs = append(obstacleErrs, &ObstacleError{Vertices: region.Vertices, Err: ErrInvalidCost})
+		}
+		for _, err := range obstacleErrs {
+			err.(*ObstacleError).Index = i
+			errs = append(errs, err)
+		}
+		normalized[i] = Region{Vertices: obstacle.Vertices, Cost: region.Cost}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	m.regions = append(m.regions, normalized...)
+	return nil
+}
+
+// Regions returns the regions added to the map.
+func (m *Map) Regions() []Region {
+	return m.regions
+}
+
+func (m *Map) ClearRegions() {
+	m.regions = nil
+}
+
+// FindWeightedShortestPath finds an approximately cheapest path from S to T
+// across the map's regions around the obstacles of its Scene. The path may
+// bend at S, T, obstacle and region vertices and at SteinerPoints points spread
+// evenly over every region edge, and only crosses region edges there; more
+// points give cheaper paths at a higher cost. Results.Length is set to the
+// Euclidean length of the path.
+func (m *Map) FindWeightedShortestPath() (WeightedPath, error) {
+	m.Results = Results{}
+	if err := m.checkEndpoints(); err != nil {
+		return WeightedPath{}, err
+	}
+
+	steinerPoints := m.SteinerPoints
+	if steinerPoints <= 0 {
+		steinerPoints = DefaultSteinerPoints
+	}
+	result, err := newWeightedGraph(m.Scene(), m.regions, steinerPoints).search(m.S, m.T)
+	m.Results.Path = result.Path
+	m.Results.Length = pathLength(result.Path)
+	return result, err
+}
+
+// weightedGraph joins two of its nodes that see each other and lie in or on
+// the same region, or outside of every region with the segment between them
+// staying outside, by an edge costing the sum of the lengths of its parts in
+// each region times the region's cost. Edges are only looked at when the
+// search reaches them.
+type weightedGraph struct {
+	scene   *Scene
+	regions []Region
+	boxes   []boundingBox
+	nodes   []Point
+	// faces holds the nodes on the edges of or inside each region, and last
+	// the nodes lying outside of every region
+	faces [][]Point
+	// nodeFaces holds the indices into faces of every node
+	nodeFaces map[Point][]int
+}
+
+func newWeightedGraph(scene *Scene, regions []Region, steinerPoints int) *weightedGraph {
+	graph := &weightedGraph{scene: scene, regions: regions, nodeFaces: make(map[Point][]int)}
+	for _, obstacle := range scene.obstacles {
+		graph.nodes = append(graph.nodes, obstacle.vertices()...)
+	}
+	// The Steiner points are rounded onto their edges, so the regions they
+	// lie on are recorded rather than tested
+	edgeRegions := make(map[Point][]int)
+	for r, region := range regions {
+		graph.boxes = append(graph.boxes, newBoundingBox(region.Vertices))
+		for i, start := range region.Vertices {
+			end := region.Vertices[(i+1)%len(region.Vertices)]
+			edgeRegions[start] = append(edgeRegions[start], r)
+			graph.nodes = append(graph.nodes, start)
+			for k := 1; k <= steinerPoints; k++ {
+				t := float64(k) / float64(steinerPoints+1)
+				p := Point{start.X + t*(end.X-start.X), start.Y + t*(end.Y-start.Y)}
+				edgeRegions[p] = append(edgeRegions[p], r)
+				graph.nodes = append(graph.nodes, p)
+			}
+		}
+	}
+	slices.SortFunc(graph.nodes, comparePoints)
+	graph.nodes = slices.Compact(graph.nodes)
+
+	graph.faces = make([][]Point, len(regions)+1)
+	for _, v := range graph.nodes {
+		graph.nodeFaces[v] = graph.facesOf(v, edgeRegions[v])
+		for _, f := range graph.nodeFaces[v] {
+			graph.faces[f] = append(graph.faces[f], v)
+		}
+	}
+	return graph
+}
+
+// facesOf returns the indices into faces of the point, which lies on the
+// edges of the given regions.
+func (g *weightedGraph) facesOf(p Point, edgeRegions []int) []int {
+	faces := slices.Compact(slices.Clone(edgeRegions))
+	outside := true
+	for r, region := range g.regions {
+		if !slices.Contains(edgeRegions, r) && g.boxes[r].contains(p) && isInsideRing(p, region.Vertices) {
+			faces = append(faces, r)
+			outside = false
+		}
+	}
+	if outside {
+		faces = append(faces, len(g.regions))
+	}
+	return faces
+}
+
+// search runs Dijkstra's algorithm from start to target over the graph's
+// nodes and the two endpoints.
+func (g *weightedGraph) search(start, target Point) (WeightedPath, error) {
+	nodeFaces := func(p Point) []int {
+		if faces, ok := g.nodeFaces[p]; ok {
+			return faces
+		}
+		return g.facesOf(p, nil)
+	}
+	targetFaces := nodeFaces(target)
+
+	cost := map[Point]float64{start: 0}
+	predecessors := make(map[Point]Point)
+	settled := make(map[Point]bool)
+	pq := NewPriorityQueue()
+	pq.PushPoint(start, 0)
+	for !pq.IsEmpty() {
+		v, _ := pq.PopPoint()
+		if settled[v] {
+			continue
+		}
+		settled[v] = true
+		if v == target {
+			break
+		}
+
+		looked := make(map[Point]bool)
+		for _, f := range nodeFaces(v) {
+			candidates := g.faces[f]
+			if slices.Contains(targetFaces, f) {
+				candidates = append(slices.Clip(candidates), target)
+			}
+			for _, u := range candidates {
+				// A segment entering a region may still join nodes of that
+				// region, so only the nodes looked at fully are skipped
+				if settled[u] || looked[u] || f == len(g.regions) && !g.staysOutside(v, u) {
+					continue
+				}
+				looked[u] = true
+				if !g.sees(v, u) {
+					continue
+				}
+				c := cost[v] + g.cost(v, u)
+				if known, ok := cost[u]; !ok || c < known {
+					cost[u] = c
+					predecessors[u] = v
+					pq.PushPoint(u, c)
+				}
+			}
+		}
+	}
+
+	if !settled[target] {
+		return WeightedPath{}, fmt.Errorf("%w: %v cannot be reached from %v", ErrNoPath, target, start)
+	}
+
+	path := []Point{target}
+	for curr := target; curr != start; {
+		curr = predecessors[curr]
+		path = append(path, curr)
+	}
+	slices.Reverse(path)
+
+	var regions []int
+	for i := 0; i+1 < len(path); i++ {
+		for _, piece := range g.pieces(path[i], path[i+1]) {
+			if piece.region >= 0 && (len(regions) == 0 || regions[len(regions)-1] != piece.region) {
+				regions = append(regions, piece.region)
+			}
+		}
+	}
+
+	return WeightedPath{Path: path, Cost: cost[target], Regions: regions}, nil
+}
+
+// sees reports whether the segment between u and v avoids the obstacles.
+func (g *weightedGraph) sees(u, v Point) bool {
+	if u == v {
+		return false
+	}
+	return slices.Contains(g.scene.edges[u], v) || isSegmentFree(u, v, g.scene.obstacles)
+}
+
+func (g *weightedGraph) cost(u, v Point) float64 {
+	total := 0.0
+	for _, piece := range g.pieces(u, v) {
+		total += g.regionCost(piece.region) * piece.length
+	}
+	return total
+}
+
+// weightedPiece is a part of a segment lying in a single region, -1 when it
+// lies in none. A piece running along a region edge is outside when the other
+// side of the edge lies in no region.
+type weightedPiece struct {
+	length  float64
+	region  int
+	outside bool
+}
+
+// pieces splits the segment from u to v where it crosses region edges and
+// returns its parts in order.
+func (g *weightedGraph) pieces(u, v Point) []weightedPiece {
+	box := newBoundingBox([]Point{u, v})
+	ts := []float64{0, 1}
+	for r, region := range g.regions {
+		if !g.boxes[r].overlaps(box) {
+			continue
+		}
+		for i, a := range region.Vertices {
+			b := region.Vertices[(i+1)%len(region.Vertices)]
+			if orientation(u, v, a) == 0 && isBetween(u, v, a) {
+				ts = append(ts, segmentParameter(u, v, a))
+			}
+			if doSegmentsIntersect(u, v, a, b) && orientation(u, v, a)*orientation(u, v, b) < 0 {
+				ts = append(ts, segmentParameter(u, v, crossingPoint(Segment{u, v}, Segment{a, b})))
+			}
+		}
+	}
+	slices.Sort(ts)
+	ts = slices.Compact(ts)
+
+	// A piece running along a region edge may take the cheaper of its sides,
+	// so both sides of every piece are looked at
+	length := u.Distance(v)
+	side := Point{(u.Y - v.Y) * 1e-9, (v.X - u.X) * 1e-9}
+	var pieces []weightedPiece
+	for i := 0; i+1 < len(ts); i++ {
+		t := (ts[i] + ts[i+1]) / 2
+		midpoint := Point{u.X + t*(v.X-u.X), u.Y + t*(v.Y-u.Y)}
+		left := g.regionAt(Point{midpoint.X + side.X, midpoint.Y + side.Y})
+		right := g.regionAt(Point{midpoint.X - side.X, midpoint.Y - side.Y})
+		region := left
+		if g.regionCost(right) < g.regionCost(left) {
+			region = right
+		}
+		pieces = append(pieces, weightedPiece{length: (ts[i+1] - ts[i]) * length, region: region, outside: left < 0 || right < 0})
+	}
+	return pieces
+}
+
+// staysOutside reports whether the segment from u to v enters no region,
+// running along region edges at most.
+func (g *weightedGraph) staysOutside(u, v Point) bool {
+	for _, piece := range g.pieces(u, v) {
+		if !piece.outside {
+			return false
+		}
+	}
+	return true
+}
+
+// regionAt returns the index of the region applying at p, -1 if there is none.
+func (g *weightedGraph) regionAt(p Point) int {
+	for r := len(g.regions) - 1; r >= 0; r-- {
+		if g.boxes[r].contains(p) && isInsideRing(p, g.regions[r].Vertices) {
+			return r
+		}
+	}
+	return -1
+}
+
+func (g *weightedGraph) regionCost(region int) float64 {
+	if region < 0 {
+		return 1
+	}
+	return g.regions[region].Cost
+}
+
+// segmentParameter returns t such that p = u + t(v - u) for a point p on the
+// segment from u to v.
+func segmentParameter(u, v, p Point) float64 {
+	if math.Abs(v.X-u.X) > math.Abs(v.Y-u.Y) {
+		return (p.X - u.X) / (v.X - u.X)
+	}
+	return (p.Y - u.Y) / (v.Y - u.Y)
+}
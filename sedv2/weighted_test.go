@@ -0,0 +1,103 @@
+package sedv2
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// TestWeightedPathRefractsAtRegionBoundary crosses a band costing three times
+// as much as free space, where the cheapest path bends at both of the band's
+// edges to cross it more steeply, like light refracting. The band is wide
+// enough for going around it along its edges to cost more. The path's cost is
+// compared with the cheapest of the paths crossing the edges at the points of
+// a dense grid.
+func TestWeightedPathRefractsAtRegionBoundary(t *testing.T) {
+	const cost = 3
+	S, T := Point{0, 0}, Point{100, 200}
+	m := NewMap(S, T)
+	m.SteinerPoints = 349
+	if err := m.AddRegions(Region{Vertices: []Point{{-300, 50}, {400, 50}, {400, 150}, {-300, 150}}, Cost: cost}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := m.FindWeightedShortestPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The path enters the band at (x1, 50) and leaves it at (x2, 150)
+	best := math.Inf(1)
+	var enter, leave float64
+	for x1 := 0.0; x1 <= 100; x1 += 0.1 {
+		for x2 := x1; x2 <= 100; x2 += 0.1 {
+			c := S.Distance(Point{x1, 50}) + cost*math.Hypot(x2-x1, 100) + T.Distance(Point{x2, 150})
+			if c < best {
+				best, enter, leave = c, x1, x2
+			}
+		}
+	}
+
+	straight := S.Distance(T) / 2 * (1 + cost)
+	if !(best < straight-10) {
+		t.Fatalf("brute force cost %v is not below the straight line's %v", best, straight)
+	}
+	if result.Cost < best-1e-3 || result.Cost > best*1.005 {
+		t.Errorf("got cost %v along %v, brute force gives %v entering at x = %v and leaving at x = %v", result.Cost, result.Path, best, enter, leave)
+	}
+	if len(result.Regions) != 1 || result.Regions[0] != 0 {
+		t.Errorf("got regions %v, want [0]", result.Regions)
+	}
+
+	// The angles to the normal of the band's edges follow Snell's law, sin θ
+	// outside being cost times sin θ inside
+	if len(result.Path) != 4 {
+		t.Fatalf("got path %v, want it to bend once on each edge of the band", result.Path)
+	}
+	sine := func(a, b Point) float64 {
+		return math.Abs(b.X-a.X) / a.Distance(b)
+	}
+	outside, inside := sine(result.Path[0], result.Path[1]), sine(result.Path[1], result.Path[2])
+	if math.Abs(outside-cost*inside) > 0.05 {
+		t.Errorf("got sines %v outside and %v inside along %v, want a ratio of %v", outside, inside, result.Path, cost)
+	}
+}
+
+// TestWeightedPathAroundObstacles checks that regions off the way leave the
+// shortest path as it is, and that a road, which only makes paths cheaper,
+// does not lead the path through the obstacles.
+func TestWeightedPathAroundObstacles(t *testing.T) {
+	for seed := uint64(0); seed < 10; seed++ {
+		r := rand.New(rand.NewPCG(seed, 0))
+		m := NewMap(Point{-5, -5}, Point{95, 95})
+		if err := m.AddObstacles(randomBoxes(r, 6)...); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := m.FindShortestPath(); err != nil {
+			t.Fatal(err)
+		}
+		shortest := m.Results.Length
+
+		if err := m.AddRegions(Region{Vertices: []Point{{200, 0}, {250, 0}, {250, 50}}, Cost: 5}); err != nil {
+			t.Fatal(err)
+		}
+		result, err := m.FindWeightedShortestPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(result.Cost-shortest) > 1e-9 || len(result.Regions) != 0 {
+			t.Errorf("seed %d: cost %v along %v through regions %v, want the shortest path's length %v",
+				seed, result.Cost, result.Path, result.Regions, shortest)
+		}
+
+		if err := m.AddRegions(Region{Vertices: []Point{{-10, 40}, {100, 40}, {100, 45}, {-10, 45}}, Cost: 0.5}); err != nil {
+			t.Fatal(err)
+		}
+		if result, err = m.FindWeightedShortestPath(); err != nil {
+			t.Fatal(err)
+		}
+		if result.Cost > shortest+1e-9 || !isPathClear(result.Path, m.Obstacles()) {
+			t.Errorf("seed %d: cost %v along %v, want a clear path costing at most %v", seed, result.Cost, result.Path, shortest)
+		}
+	}
+}